@@ -0,0 +1,93 @@
+package flag
+
+import (
+	"regexp"
+	"strings"
+)
+
+// KeyCase is how AutoCfg turns the names of the sub commands and flags to cfg keys.
+type KeyCase int
+
+const (
+	KebabCase KeyCase = iota // listen-addr
+	SnakeCase                // listen_addr
+	CamelCase                // listenAddr
+)
+
+var keyWordSeparators = regexp.MustCompile(`[^a-zA-Z0-9]+`)
+
+// key returns name in the case c, the words of name are separated by any character other than a letter or a digit
+func (c KeyCase) key(name string) string {
+	var words []string
+	for _, word := range keyWordSeparators.Split(name, -1) {
+		if word != "" {
+			words = append(words, strings.ToLower(word))
+		}
+	}
+	switch c {
+	case SnakeCase:
+		return strings.Join(words, "_")
+	case CamelCase:
+		for i := 1; i < len(words); i++ {
+			words[i] = strings.ToUpper(words[i][:1]) + words[i][1:]
+		}
+		return strings.Join(words, "")
+	}
+	return strings.Join(words, "-")
+}
+
+// AutoCfg binds every flag of the command and of all of its sub commands to the cfg key
+// <sub_command>.<flag_name>, the names turned to keyCase, like server.listenAddr for the flag listen-addr
+// of the sub command server in CamelCase. flags defined before and after the call are bound,
+// cfgs bound with Cfg come first. use NoAutoCfg to opt a flag out.
+func (f *Command) AutoCfg(keyCase KeyCase) {
+	f.autoCfg = true
+	f.autoCfgCase = keyCase
+	f.bindAutoCfgRecursive()
+}
+
+// NoAutoCfg opts the flag you are defining out of AutoCfg.
+func (fs *Command) NoAutoCfg() *flagFeature {
+	return &flagFeature{
+		index: 8,
+		add: func(fs *Command, f *Flag) {
+			f.skipCfg = true
+		},
+	}
+}
+
+// bindAutoCfgRecursive binds the flags already defined on f and its sub commands to their auto cfg
+func (f *Command) bindAutoCfgRecursive() {
+	for _, flag := range f.formal {
+		f.bindAutoCfg(flag)
+	}
+	for _, sc := range f.SubCmds {
+		sc.fs.bindAutoCfgRecursive()
+	}
+}
+
+// bindAutoCfg binds the flag to its auto cfg, if f or any of its parents called AutoCfg
+func (f *Command) bindAutoCfg(flag *Flag) {
+	if flag.aliasFor != "" || flag.skipCfg {
+		return
+	}
+	if key, ok := f.autoCfgKey(flag.Name); ok {
+		f.bindCfg(flag, key)
+	}
+}
+
+// autoCfgKey returns the auto cfg of the flag name defined on f, false if neither f nor its parents called AutoCfg
+func (f *Command) autoCfgKey(name string) (string, bool) {
+	names := []string{name}
+	for c := f; c != nil; c = c.parentCmd {
+		if c.autoCfg {
+			keys := make([]string, len(names))
+			for i, name := range names {
+				keys[len(names)-1-i] = c.autoCfgCase.key(name)
+			}
+			return strings.Join(keys, "."), true
+		}
+		names = append(names, c.name)
+	}
+	return "", false
+}
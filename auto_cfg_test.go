@@ -0,0 +1,61 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestAutoCfg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	err := os.WriteFile(path, []byte("logLevel: debug\nserver:\n  listenAddr: \":9000\"\n  workers: 8\n  threads: 2\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := OneCmd("app", ContinueOnError)
+	fs.AutoCfg(CamelCase)
+	level := fs.String("log-level", "info", "")
+	var addr string
+	var workers int
+	fs.SubCmd("server", "", func(cmd Cmd, args []string) {
+		cmd.StringVar(&addr, "listen-addr", ":80", "")
+		cmd.IntVar(&workers, "workers", 1, "", cmd.Cfg("server.threads"))
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err = fs.LoadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.Parse([]string{"server"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *level != "debug" || addr != ":9000" {
+		t.Errorf("expected level debug and addr :9000 but got %v and %v", *level, addr)
+	}
+	if workers != 2 {
+		t.Errorf("expected the cfg bound with Cfg to come first but got workers %v", workers)
+	}
+}
+
+func TestAutoCfg_KeyCase(t *testing.T) {
+	for keyCase, want := range map[KeyCase]string{KebabCase: "my-server.listen-addr", SnakeCase: "my_server.listen_addr", CamelCase: "myServer.listenAddr"} {
+		fs := OneCmd("app", ContinueOnError)
+		fs.AutoCfg(keyCase)
+		server := fs.NewSubCmd("my-server", "", nil)
+		server.String("listen-addr", "", "")
+		server.String("secret", "", "", server.NoAutoCfg())
+		if got := server.Lookup("listen-addr").Cfgs(); !reflect.DeepEqual(got, []string{want}) {
+			t.Errorf("%v: expected the cfg %v but got %v", keyCase, want, got)
+		}
+		if got := server.Lookup("secret").Cfgs(); len(got) != 0 {
+			t.Errorf("%v: expected the opted out flag to have no cfg but got %v", keyCase, got)
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package flag
+
+import (
+	"strings"
+)
+
+// AutoEnv binds every flag of the command and of all of its sub commands to the env
+// PREFIX_<SUB_COMMAND>_<FLAG_NAME>, upper cased with the characters other than letters, digits and _ turned to _,
+// like APP_SERVE_LISTEN_ADDR for the flag listen-addr of the sub command serve with the prefix app.
+// flags defined before and after the call are bound, envs bound with Env come first,
+// the flags defined before the call get the value of their auto env on Parse.
+// use NoAutoEnv to opt a flag out.
+func (f *Command) AutoEnv(prefix string) {
+	f.autoEnv = true
+	f.autoEnvPrefix = prefix
+	f.bindAutoEnvRecursive()
+}
+
+// NoAutoEnv opts the flag you are defining out of AutoEnv.
+func (fs *Command) NoAutoEnv() *flagFeature {
+	return &flagFeature{
+		index: 7,
+		add: func(fs *Command, f *Flag) {
+			f.skipEnv = true
+		},
+	}
+}
+
+// bindAutoEnvRecursive binds the flags already defined on f and its sub commands to their auto env
+func (f *Command) bindAutoEnvRecursive() {
+	for _, flag := range f.formal {
+		f.bindAutoEnv(flag)
+	}
+	for _, sc := range f.SubCmds {
+		sc.fs.bindAutoEnvRecursive()
+	}
+}
+
+// bindAutoEnv binds the flag to its auto env, if f or any of its parents called AutoEnv
+func (f *Command) bindAutoEnv(flag *Flag) {
+	if flag.aliasFor != "" || flag.skipEnv {
+		return
+	}
+	if env, ok := f.autoEnvName(flag.Name); ok {
+		f.bindEnv(flag, env)
+	}
+}
+
+// autoEnvName returns the auto env of the flag name defined on f, false if neither f nor its parents called AutoEnv
+func (f *Command) autoEnvName(name string) (string, bool) {
+	parts := []string{name}
+	for c := f; c != nil; c = c.parentCmd {
+		if c.autoEnv {
+			if c.autoEnvPrefix != "" {
+				parts = append(parts, c.autoEnvPrefix)
+			}
+			for i, j := 0, len(parts)-1; i < j; i, j = i+1, j-1 {
+				parts[i], parts[j] = parts[j], parts[i]
+			}
+			return strings.ToUpper(nonIdentifierChars.ReplaceAllString(strings.Join(parts, "_"), "_")), true
+		}
+		parts = append(parts, c.name)
+	}
+	return "", false
+}
@@ -0,0 +1,88 @@
+package flag_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestAutoEnv(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	t.Setenv("APP_VERBOSE", "true")
+	t.Setenv("APP_SERVE_LISTEN_ADDR", ":9000")
+	t.Setenv("APP_SERVE_SECRET", "leaked")
+	t.Setenv("APP_SERVE_WORKERS", "8")
+	t.Setenv("WORKERS", "2")
+
+	fs := OneCmd("app", ContinueOnError)
+	verbose := fs.Bool("verbose", false, "defined before AutoEnv")
+	fs.AutoEnv("app")
+	level := fs.String("log-level", "info", "")
+	var addr, secret string
+	var workers int
+	var usage string
+	fs.SubCmd("serve", "", func(cmd Cmd, args []string) {
+		cmd.StringVar(&addr, "listen-addr", ":80", "")
+		cmd.StringVar(&secret, "secret", "", "", cmd.NoAutoEnv())
+		cmd.IntVar(&workers, "workers", 1, "", cmd.Env("WORKERS"))
+		usage, _ = cmd.GetDefaultUsageLong()
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := fs.Parse([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.ParseWithoutArgs(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*verbose || *level != "debug" {
+		t.Errorf("expected verbose and level debug from the auto envs but got %v and %v", *verbose, *level)
+	}
+	if addr != ":9000" || secret != "" {
+		t.Errorf("expected addr :9000 from the auto env and no secret but got %v and %v", addr, secret)
+	}
+	if workers != 2 {
+		t.Errorf("expected the env bound with Env to come first but got workers %v", workers)
+	}
+	if !strings.Contains(usage, `"APP_SERVE_LISTEN_ADDR"`) || strings.Contains(usage, "APP_SERVE_SECRET") {
+		t.Errorf("expected the auto envs in the long usage but got\n%v", usage)
+	}
+}
+
+func TestAutoEnv_SubCmd(t *testing.T) {
+	t.Setenv("PORT", "7000")
+	fs := OneCmd("app", ContinueOnError)
+	var port int
+	fs.SubCmd("serve", "", func(cmd Cmd, args []string) {
+		cmd.AutoEnv("")
+		cmd.IntVar(&port, "port", 80, "")
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := fs.Parse([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 7000 {
+		t.Errorf("expected the auto env of a sub command to be relative to it but got port %v", port)
+	}
+}
+
+func TestAutoEnv_CfgFlag(t *testing.T) {
+	fs := OneCmd("app", ContinueOnError)
+	fs.SetCfgFile("app")
+	fs.AutoEnv("tool")
+	fs.AutoCfg(KebabCase)
+	config := fs.Lookup(CfgFlagName)
+	if !reflect.DeepEqual(config.Envs(), []string{"APP_CONFIG"}) || len(config.Cfgs()) != 0 {
+		t.Errorf("expected the config flag to be bound only to APP_CONFIG but got envs %v and cfgs %v", config.Envs(), config.Cfgs())
+	}
+}
@@ -0,0 +1,257 @@
+package flag
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BindStruct defines a flag for every tagged field of the struct ptr points to,
+// the current value of the field is the default value of the flag.
+// supported tags are
+//
+//	flag:"port"         name of the flag, fields without it are skipped, "-" skips the field
+//	usage:"..."         usage of the flag
+//	env:"PORT,APP_PORT" same as Env("PORT", "APP_PORT")
+//	cfg:"server.port"   same as Cfg("server.port")
+//	alias:"p"           same as Alias("p")
+//	enum:"a,b"          same as Enum("a", "b")
+//	required:"true"     same as Required()
+//
+// a nested struct field is walked recursively, its cfg tag (or its lower cased field name) is prefixed
+// to the cfg keys of its fields with a dot, and its flag tag if any is prefixed to the flag names of its fields with a dash.
+// embedded structs are walked without any prefix. a pointer to a struct is walked like a struct, when nil it is set
+// to a new struct if the new struct has flags, a pointer back to a struct being walked is skipped (an error if tagged with flag).
+//
+// fields can be of type bool, int, int64, uint, uint64, string, float64, time.Duration,
+// []string, []int, []float64, []time.Duration, map[string]string, map[string]int or any type whose pointer implements Value or encoding.TextUnmarshaler.
+// the tags of all the fields are checked before any flag is defined, an invalid tag is returned as an error.
+func (f *Command) BindStruct(ptr any) error {
+	v := reflect.ValueOf(ptr)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("BindStruct needs a pointer to a struct but got %T", ptr)
+	}
+	var flags []*structFlag
+	err := f.collectStruct(v.Elem(), "", "", map[reflect.Type]bool{}, &flags)
+	if err != nil {
+		return err
+	}
+	err = f.checkStructFlags(flags)
+	if err != nil {
+		return err
+	}
+	for _, sf := range flags {
+		_, err := f.varErr(sf.value, sf.name, sf.usage, sf.features(f)...)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// structFlag is a flag BindStruct defines for a field
+type structFlag struct {
+	field    string
+	value    Value
+	name     string
+	usage    string
+	envs     []string
+	cfgs     []string
+	aliases  []string
+	enums    []string
+	required bool
+}
+
+// features returns the features of the flag set by the tags of the field
+func (sf *structFlag) features(f *Command) []*flagFeature {
+	var features []*flagFeature
+	if len(sf.envs) > 0 {
+		features = append(features, f.Env(sf.envs...))
+	}
+	if len(sf.cfgs) > 0 {
+		features = append(features, f.Cfg(sf.cfgs...))
+	}
+	if len(sf.aliases) > 0 {
+		features = append(features, f.Alias(sf.aliases...))
+	}
+	if len(sf.enums) > 0 {
+		features = append(features, f.Enum(sf.enums...))
+	}
+	if sf.required {
+		features = append(features, f.Required())
+	}
+	return features
+}
+
+// collectStruct appends a structFlag for every tagged field of the struct v to flags,
+// walking contains the struct types being walked, which a pointer field can't point back to
+func (f *Command) collectStruct(v reflect.Value, flagPrefix string, cfgPrefix string, walking map[reflect.Type]bool, flags *[]*structFlag) error {
+	t := v.Type()
+	walking[t] = true
+	defer delete(walking, t)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		name := field.Tag.Get("flag")
+		if name == "-" {
+			continue
+		}
+		fieldValue := v.Field(i)
+		value := structFieldValue(fieldValue)
+		if value == nil && field.Type.Kind() == reflect.Pointer && field.Type.Elem().Kind() == reflect.Struct {
+			if walking[field.Type.Elem()] {
+				// a recursive type like a linked list, it would define flags forever
+				if name != "" {
+					return fmt.Errorf("the field %v.%v points back to the struct %v", t.Name(), field.Name, field.Type.Elem())
+				}
+				continue
+			}
+			nested := fieldValue
+			if fieldValue.IsNil() {
+				nested = reflect.New(field.Type.Elem())
+			}
+			n := len(*flags)
+			err := f.collectNested(field, nested.Elem(), flagPrefix, cfgPrefix, walking, flags)
+			if err != nil {
+				return err
+			}
+			if fieldValue.IsNil() && len(*flags) > n {
+				// the new struct has flags
+				fieldValue.Set(nested)
+			}
+			continue
+		}
+		if value == nil && field.Type.Kind() == reflect.Struct {
+			err := f.collectNested(field, fieldValue, flagPrefix, cfgPrefix, walking, flags)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if name == "" {
+			continue
+		}
+		sf := &structFlag{field: t.Name() + "." + field.Name, value: value, name: flagPrefix + name, usage: field.Tag.Get("usage")}
+		if value == nil {
+			return fmt.Errorf("unsupported type %v of the field %v for flag %v", field.Type, sf.field, sf.name)
+		}
+		var err error
+		for _, tag := range []struct {
+			key string
+			to  *[]string
+		}{{"env", &sf.envs}, {"cfg", &sf.cfgs}, {"alias", &sf.aliases}, {"enum", &sf.enums}} {
+			*tag.to, err = splitTag(field.Tag.Get(tag.key))
+			if err != nil {
+				return fmt.Errorf("invalid %v tag of the field %v : %v", tag.key, sf.field, err)
+			}
+		}
+		for i := range sf.cfgs {
+			sf.cfgs[i] = cfgPrefix + sf.cfgs[i]
+		}
+		if required, ok := field.Tag.Lookup("required"); ok {
+			sf.required, err = strconv.ParseBool(required)
+			if err != nil {
+				return fmt.Errorf("invalid required tag of the field %v : %q is not a bool", sf.field, required)
+			}
+		}
+		*flags = append(*flags, sf)
+	}
+	return nil
+}
+
+// collectNested collects the struct v of the field, embedded structs without a prefix
+func (f *Command) collectNested(field reflect.StructField, v reflect.Value, flagPrefix string, cfgPrefix string, walking map[reflect.Type]bool, flags *[]*structFlag) error {
+	if field.Anonymous {
+		return f.collectStruct(v, flagPrefix, cfgPrefix, walking, flags)
+	}
+	if name := field.Tag.Get("flag"); name != "" {
+		flagPrefix += name + "-"
+	}
+	nestedCfgPrefix := field.Tag.Get("cfg")
+	if nestedCfgPrefix == "" {
+		nestedCfgPrefix = strings.ToLower(field.Name)
+	}
+	return f.collectStruct(v, flagPrefix, cfgPrefix+nestedCfgPrefix+".", walking, flags)
+}
+
+// checkStructFlags returns an error for the flags BindStruct can't define, before any of them is defined
+func (f *Command) checkStructFlags(flags []*structFlag) error {
+	names := make(map[string]string)
+	for _, sf := range flags {
+		for _, name := range append([]string{sf.name}, sf.aliases...) {
+			if strings.HasPrefix(name, "-") || strings.Contains(name, "=") {
+				return fmt.Errorf("invalid flag name %q of the field %v", name, sf.field)
+			}
+			if f.formal[name] != nil {
+				return fmt.Errorf("flag %v of the field %v is already defined", name, sf.field)
+			}
+			if other, ok := names[name]; ok {
+				return fmt.Errorf("flag %v of the field %v is already used by the field %v", name, sf.field, other)
+			}
+			names[name] = sf.field
+		}
+		if len(sf.enums) > 0 && !isEnumValid(sf.value.String(), sf.enums) {
+			return fmt.Errorf("the value %q of the field %v is not one of its enums %v", sf.value.String(), sf.field, sf.enums)
+		}
+	}
+	return nil
+}
+
+// structFieldValue returns a Value which sets the field v, nil if the type of the field is not supported
+func structFieldValue(v reflect.Value) Value {
+	switch p := v.Addr().Interface().(type) {
+	case Value:
+		return p
+	case *bool:
+		return (*boolValue)(p)
+	case *int:
+		return (*intValue)(p)
+	case *int64:
+		return (*int64Value)(p)
+	case *uint:
+		return (*uintValue)(p)
+	case *uint64:
+		return (*uint64Value)(p)
+	case *string:
+		return (*stringValue)(p)
+	case *float64:
+		return (*float64Value)(p)
+	case *time.Duration:
+		return (*durationValue)(p)
+	case *[]string:
+		return newSliceValue(*p, p, parseString, formatString)
+	case *[]int:
+		return newSliceValue(*p, p, parseInt, strconv.Itoa)
+	case *[]float64:
+		return newSliceValue(*p, p, parseFloat64, formatFloat64)
+	case *[]time.Duration:
+		return newSliceValue(*p, p, parseDuration, formatDuration)
+	case *map[string]string:
+		return newMapValue(*p, p, parseString, formatString)
+	case *map[string]int:
+		return newMapValue(*p, p, parseInt, strconv.Itoa)
+	case encoding.TextUnmarshaler:
+		return textValue{p}
+	}
+	return nil
+}
+
+// splitTag splits a comma separated tag value, an empty entry is an error
+func splitTag(tag string) ([]string, error) {
+	if tag == "" {
+		return nil, nil
+	}
+	parts := strings.Split(tag, ",")
+	for i := range parts {
+		parts[i] = strings.TrimSpace(parts[i])
+		if parts[i] == "" {
+			return nil, fmt.Errorf("empty entry in %q", tag)
+		}
+	}
+	return parts, nil
+}
@@ -0,0 +1,166 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+type testServerCfg struct {
+	Port int    `flag:"port" usage:"port to listen on" env:"TEST_BIND_PORT" alias:"p"`
+	Host string `flag:"host" cfg:"host"`
+}
+
+type testAppCfg struct {
+	Mode    string        `flag:"mode" enum:"dev,prod"`
+	Verbose bool          `flag:"verbose"`
+	Timeout time.Duration `flag:"timeout"`
+	Server  testServerCfg `flag:"server" cfg:"srv"`
+	Ignored string
+	Skipped string `flag:"-"`
+}
+
+func TestBindStruct(t *testing.T) {
+	dir := t.TempDir()
+	cfgFile := filepath.Join(dir, "cfg.yaml")
+	err := os.WriteFile(cfgFile, []byte("srv:\n  host: example.com\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_BIND_PORT", "8080")
+
+	cmd := OneCmd("app", ContinueOnError)
+	err = cmd.LoadCfg(cfgFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := testAppCfg{Mode: "dev", Timeout: time.Second}
+	err = cmd.BindStruct(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 8080 {
+		t.Errorf("expected port from env to be 8080 but got %v", cfg.Server.Port)
+	}
+	if cfg.Server.Host != "example.com" {
+		t.Errorf("expected host from cfg to be example.com but got %v", cfg.Server.Host)
+	}
+	err = cmd.Parse([]string{"--mode", "prod", "--verbose", "--timeout", "1m", "--p", "9090"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := testAppCfg{Mode: "prod", Verbose: true, Timeout: time.Minute, Server: testServerCfg{Port: 9090, Host: "example.com"}}
+	if cfg != want {
+		t.Errorf("expected %+v but got %+v", want, cfg)
+	}
+	err = cmd.Parse([]string{"--mode", "staging"})
+	if err == nil {
+		t.Error("expected error for a value not in enum")
+	}
+	usage, _ := cmd.GetDefaultUsageLong()
+	for _, s := range []string{"--server-port", "--server-host", `binds to cfg/s ["srv.host"]`, "port to listen on"} {
+		if !strings.Contains(usage, s) {
+			t.Errorf("expected usage to contain %v:\n%v", s, usage)
+		}
+	}
+	if strings.Contains(usage, "Ignored") || strings.Contains(usage, "Skipped") {
+		t.Errorf("fields without flag tag should be skipped:\n%v", usage)
+	}
+
+	err = cmd.BindStruct(cfg)
+	if err == nil {
+		t.Error("expected error for a non pointer")
+	}
+	err = cmd.BindStruct(&struct {
+		C chan int `flag:"c"`
+	}{})
+	if err == nil {
+		t.Error("expected error for an unsupported field type")
+	}
+}
+
+func TestBindStruct_InvalidTags(t *testing.T) {
+	tests := map[string]any{
+		"enum without the default": &struct {
+			Mode string `flag:"mode" enum:"dev,prod"`
+		}{},
+		"empty env": &struct {
+			Port int `flag:"port" env:"PORT,"`
+		}{},
+		"required not a bool": &struct {
+			Port int `flag:"port" required:"yes"`
+		}{},
+		"alias used twice": &struct {
+			Port int `flag:"port" alias:"p"`
+			Path int `flag:"path" alias:"p"`
+		}{},
+		"bad name": &struct {
+			Port int `flag:"-port"`
+		}{},
+	}
+	for name, ptr := range tests {
+		cmd := OneCmd("app", ContinueOnError)
+		cmd.Int("defined", 0, "")
+		err := cmd.BindStruct(ptr)
+		if err == nil {
+			t.Errorf("%v: expected an error", name)
+		}
+		if cmd.Lookup("port") != nil || cmd.Lookup("mode") != nil {
+			t.Errorf("%v: expected no flag to be defined after an error", name)
+		}
+	}
+	err := OneCmd("app", ContinueOnError).BindStruct(&struct {
+		Port int `flag:"port"`
+		Name int `flag:"name" alias:"port"`
+	}{})
+	if err == nil {
+		t.Error("expected an error for an alias named like another flag")
+	}
+}
+
+type testNode struct {
+	Name string    `flag:"name"`
+	Next *testNode `cfg:"next"`
+}
+
+type testLoop struct {
+	Next *testLoop `flag:"next"`
+}
+
+func TestBindStruct_Pointer(t *testing.T) {
+	var cfg struct {
+		Server *testServerCfg `flag:"server"`
+		Empty  *struct{ X int }
+		Node   testNode
+	}
+	cmd := OneCmd("app", ContinueOnError)
+	err := cmd.BindStruct(&cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server == nil || cfg.Empty != nil {
+		t.Fatalf("expected only the pointer to a struct with flags to be set but got %v and %v", cfg.Server, cfg.Empty)
+	}
+	err = cmd.Parse([]string{"--server-port", "90", "--name", "n"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Server.Port != 90 || cfg.Node.Name != "n" {
+		t.Errorf("expected port 90 and name n but got %v and %v", cfg.Server.Port, cfg.Node.Name)
+	}
+	err = OneCmd("app", ContinueOnError).BindStruct(&struct {
+		Next *testNode `flag:"next"`
+		Node testNode  `flag:"node"`
+	}{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = OneCmd("app", ContinueOnError).BindStruct(&testLoop{})
+	if err == nil {
+		t.Error("expected an error for a flag tagged pointer back to its own struct")
+	}
+}
@@ -0,0 +1,114 @@
+package flag
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// CfgFlagName is the name of the flag SetCfgFile defines to pass the path of the config file
+const CfgFlagName = "config"
+
+// cfgExtensions are the extensions tried while searching a config file, in order, every one of them is understood by LoadCfg
+var cfgExtensions = []string{".yaml", ".yml", ".json", ".toml", ".properties", ".ini", ".hcl"}
+
+// cfgFile holds what SetCfgFile declared
+type cfgFile struct {
+	name        string
+	searchPaths []string
+	flag        *Flag
+}
+
+// SetCfgFile makes Parse load a config file named name with any extension LoadCfg supports.
+// the path is taken from the --config flag, or the env <NAME>_CONFIG, otherwise the first file found
+// in the searchPaths (envs like $HOME are expanded) is loaded, the default search paths are
+//
+//	.
+//	$XDG_CONFIG_HOME/<name> ($HOME/.config/<name> if XDG_CONFIG_HOME is not set)
+//	$HOME/.<name>
+//	/etc/<name>
+//
+// no config file found is not an error, a --config pointing to a missing file is.
+// the --config flag is left out of AutoEnv and AutoCfg.
+func (f *Command) SetCfgFile(name string, searchPaths ...string) {
+	envName := strings.ToUpper(nonIdentifierChars.ReplaceAllString(name, "_")) + "_CONFIG"
+	f.String(CfgFlagName, "", "path of the config file, looks for "+name+".{"+strings.Join(trimDots(cfgExtensions), ",")+"} if not set", f.Env(envName), f.NoAutoEnv(), f.NoAutoCfg())
+	f.cfgFile = &cfgFile{name: name, searchPaths: searchPaths, flag: f.formal[CfgFlagName]}
+}
+
+// CfgPath returns the path of the config file loaded to the command tree (the last one if LoadCfgs loaded many), empty if none is loaded.
+func (f *Command) CfgPath() string {
+	return f.root().cfgPath
+}
+
+// loadCfgFile loads the config file declared with SetCfgFile, if any.
+// a path is read once, while it is the loaded config of the tree, WatchCfg picks up the changes to it.
+func (f *Command) loadCfgFile() error {
+	if f.cfgFile == nil {
+		return nil
+	}
+	err := f.resolve(f.cfgFile.flag)
+	if err != nil {
+		return err
+	}
+	path := f.cfgFile.flag.Value.String()
+	if path == "" {
+		path = f.cfgFile.find()
+		if path == "" {
+			return nil
+		}
+	}
+	root := f.root()
+	if root.cfgFileLoaded == path && root.cfgPath == path {
+		return nil
+	}
+	err = f.LoadCfg(path)
+	if err != nil {
+		return err
+	}
+	root.cfgFileLoaded = path
+	return nil
+}
+
+// find returns the first existing config file in the search paths, empty if there is none
+func (c *cfgFile) find() string {
+	paths := c.searchPaths
+	if len(paths) == 0 {
+		paths = defaultCfgSearchPaths(c.name)
+	}
+	for _, dir := range paths {
+		dir = os.ExpandEnv(dir)
+		for _, ext := range cfgExtensions {
+			path := filepath.Join(dir, c.name+ext)
+			if info, err := os.Stat(path); err == nil && !info.IsDir() {
+				return path
+			}
+		}
+	}
+	return ""
+}
+
+// defaultCfgSearchPaths returns the directories searched for the config file named name when no search paths are passed
+func defaultCfgSearchPaths(name string) []string {
+	paths := []string{"."}
+	home, _ := os.UserHomeDir()
+	xdg := os.Getenv("XDG_CONFIG_HOME")
+	if xdg == "" && home != "" {
+		xdg = filepath.Join(home, ".config")
+	}
+	if xdg != "" {
+		paths = append(paths, filepath.Join(xdg, name))
+	}
+	if home != "" {
+		paths = append(paths, filepath.Join(home, "."+name))
+	}
+	return append(paths, filepath.Join("/etc", name))
+}
+
+func trimDots(exts []string) []string {
+	trimmed := make([]string, len(exts))
+	for i, ext := range exts {
+		trimmed[i] = strings.TrimPrefix(ext, ".")
+	}
+	return trimmed
+}
@@ -0,0 +1,157 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func writeCfg(t *testing.T, path string, content string) {
+	t.Helper()
+	err := os.MkdirAll(filepath.Dir(path), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSetCfgFile(t *testing.T) {
+	home := t.TempDir()
+	xdg := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	t.Setenv("TESTAPP_CONFIG", "")
+	homeCfg := filepath.Join(home, ".testapp", "testapp.toml")
+	xdgCfg := filepath.Join(xdg, "testapp", "testapp.json")
+	writeCfg(t, homeCfg, "port = 7000\n")
+	writeCfg(t, xdgCfg, `{"port": 8000}`)
+
+	parse := func(args ...string) (int, string, error) {
+		fs := OneCmd("testapp", ContinueOnError)
+		fs.SetCfgFile("testapp")
+		port := fs.Int("port", 1, "", fs.Cfg("port"))
+		err := fs.Parse(args)
+		return *port, fs.CfgPath(), err
+	}
+
+	// $XDG_CONFIG_HOME/<name> comes before $HOME/.<name>
+	port, path, err := parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 8000 || path != xdgCfg {
+		t.Errorf("expected port 8000 from %v but got %v from %v", xdgCfg, port, path)
+	}
+
+	os.Remove(xdgCfg)
+	port, path, err = parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 7000 || path != homeCfg {
+		t.Errorf("expected port 7000 from %v but got %v from %v", homeCfg, port, path)
+	}
+
+	explicit := filepath.Join(t.TempDir(), "other.yaml")
+	writeCfg(t, explicit, "port: 9000\n")
+	port, path, err = parse("--config", explicit)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 9000 || path != explicit {
+		t.Errorf("expected port 9000 from %v but got %v from %v", explicit, port, path)
+	}
+
+	t.Setenv("TESTAPP_CONFIG", explicit)
+	port, _, err = parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 9000 {
+		t.Errorf("expected port 9000 from the env override but got %v", port)
+	}
+
+	_, _, err = parse("--config", filepath.Join(home, "missing.yaml"))
+	if err == nil {
+		t.Error("expected an error for a missing --config file")
+	}
+
+	os.Remove(homeCfg)
+	t.Setenv("TESTAPP_CONFIG", "")
+	port, path, err = parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 1 || path != "" {
+		t.Errorf("expected the default port without a config file but got %v from %v", port, path)
+	}
+}
+
+func TestSetCfgFile_SearchPaths(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("TEST_CFG_DIR", dir)
+	writeCfg(t, filepath.Join(dir, "app.yml"), "name: found\n")
+
+	fs := OneCmd("app", ContinueOnError)
+	fs.SetCfgFile("app", "/nonexistent", "$TEST_CFG_DIR")
+	var name string
+	fs.SubCmd("run", "", func(cmd Cmd, args []string) {
+		cmd.StringVar(&name, "name", "", "", cmd.Cfg("name"))
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := fs.Parse([]string{"run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "found" {
+		t.Errorf("expected the sub command to bind to the discovered config but got %q", name)
+	}
+}
+
+func TestSetCfgFile_LoadedOnce(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "testapp.yaml")
+	writeCfg(t, path, "port: 7000\n")
+	fs := OneCmd("testapp", ContinueOnError)
+	fs.SetCfgFile("testapp", dir)
+	var port int
+	fs.SubCmd("serve", "", func(cmd Cmd, args []string) {
+		if cmd.Lookup("port") == nil {
+			cmd.IntVar(&port, "port", 1, "", cmd.Cfg("port"))
+		}
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := fs.Parse([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// not read again by the next Parse, WatchCfg is the one picking up the changes
+	writeCfg(t, path, "port: 8000\n")
+	err = fs.Parse([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 7000 {
+		t.Errorf("expected the config file to be read once but got port %v", port)
+	}
+	other := filepath.Join(t.TempDir(), "other.yaml")
+	writeCfg(t, other, "port: 9000\n")
+	err = fs.Parse([]string{"--config", other, "serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if port != 9000 {
+		t.Errorf("expected another --config to be read but got port %v", port)
+	}
+}
@@ -2,38 +2,284 @@ package flag
 
 import (
 	"bytes"
+	"encoding/csv"
 	"encoding/json"
-	"errors"
 	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/BurntSushi/toml"
 	"github.com/go-yaml/yaml"
+	"github.com/hashicorp/hcl"
 )
 
 // EnvToMap parses an environment file content and returns the key-value pairs as a map.
+// it follows the dotenv format of docker compose
+//
+//	# comments and blank lines are skipped
+//	export KEY=value          # export is optional, so is the white space around =, inline comments follow a white space
+//	KEY='literal $value'      # single quotes keep the value as it is, and can span lines
+//	KEY="a\tb\n${OTHER}"      # double quotes support \n, \r, \t, \\, \", \$ and can span lines
+//	KEY=${OTHER:-default}     # $VAR, ${VAR}, ${VAR:-default} and ${VAR-default} are expanded in unquoted and double quoted values,
+//	KEY=${OTHER:?error}       # so are ${VAR:?error} and ${VAR?error}, failing if VAR is unset (or empty with :), and
+//	KEY=${OTHER:+alternate}   # ${VAR:+alternate} and ${VAR+alternate}, alternate if VAR is set (and not empty with :), empty otherwise
+//	KEY                       # a key alone takes the value of the environment, if set
+//
+// variables are expanded from the keys defined above them in the content first, then from the environment.
+// errors report the line they occurred on.
 func EnvToMap(content string) (map[string]string, error) {
-	envMap := make(map[string]string)
+	return parseEnv(content, os.LookupEnv)
+}
 
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" || strings.HasPrefix(line, "#") {
-			continue
+// parseEnv is EnvToMap looking up the variables not defined in the content with lookupEnv
+func parseEnv(content string, lookupEnv func(key string) (string, bool)) (map[string]string, error) {
+	p := &envParser{src: strings.ReplaceAll(content, "\r\n", "\n"), line: 1, vars: make(map[string]string), lookupEnv: lookupEnv}
+	for {
+		p.skipBlank()
+		if p.pos >= len(p.src) {
+			return p.vars, nil
+		}
+		switch p.src[p.pos] {
+		case '\n':
+			p.pos++
+			p.line++
+		case '#':
+			p.skipLine()
+		default:
+			line := p.line
+			err := p.parseEntry()
+			if err != nil {
+				return nil, fmt.Errorf("line %v: %v", line, err)
+			}
 		}
+	}
+}
+
+var envKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_.-]*$`)
+
+// envVarName matches the name of a variable at the start of a ${} expression
+var envVarName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*`)
 
-		parts := strings.SplitN(line, "=", 2)
-		if len(parts) != 2 {
-			return nil, errors.New("invalid line format: " + line)
+// envParser reads the entries of a dotenv content one by one
+type envParser struct {
+	src  string
+	pos  int
+	line int
+	vars map[string]string
+
+	lookupEnv func(key string) (string, bool)
+}
+
+// skipBlank skips spaces and tabs
+func (p *envParser) skipBlank() {
+	for p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.pos++
+	}
+}
+
+// skipLine skips up to and including the next new line
+func (p *envParser) skipLine() {
+	for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+		p.pos++
+	}
+	if p.pos < len(p.src) {
+		p.pos++
+		p.line++
+	}
+}
+
+// endOfLine reports whether only blanks or a comment are left on the line
+func (p *envParser) endOfLine() bool {
+	p.skipBlank()
+	if p.pos >= len(p.src) {
+		return true
+	}
+	if p.src[p.pos] == '#' || p.src[p.pos] == '\n' {
+		p.skipLine()
+		return true
+	}
+	return false
+}
+
+func (p *envParser) readKey() string {
+	start := p.pos
+	for p.pos < len(p.src) && !strings.ContainsRune("= \t\n#", rune(p.src[p.pos])) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+// parseEntry parses a single KEY=value entry
+func (p *envParser) parseEntry() error {
+	key := p.readKey()
+	if key == "export" && p.pos < len(p.src) && (p.src[p.pos] == ' ' || p.src[p.pos] == '\t') {
+		p.skipBlank()
+		key = p.readKey()
+	}
+	if !envKey.MatchString(key) {
+		return fmt.Errorf("invalid key %q", key)
+	}
+	if p.endOfLine() {
+		// a key alone passes the value of the environment through
+		if v, ok := p.lookupEnv(key); ok {
+			p.vars[key] = v
 		}
+		return nil
+	}
+	if p.src[p.pos] != '=' {
+		return fmt.Errorf("expected = after key %v", key)
+	}
+	p.pos++
+	p.skipBlank()
+	var value string
+	var err error
+	if p.pos < len(p.src) && (p.src[p.pos] == '\'' || p.src[p.pos] == '"') {
+		value, err = p.readQuoted()
+		if err != nil {
+			return err
+		}
+		if !p.endOfLine() {
+			return fmt.Errorf("unexpected %q after the quoted value of %v", p.src[p.pos], key)
+		}
+	} else {
+		value, err = p.readUnquoted()
+		if err != nil {
+			return err
+		}
+	}
+	p.vars[key] = value
+	return nil
+}
 
-		key := strings.TrimSpace(parts[0])
-		value := strings.TrimSpace(parts[1])
+// readUnquoted reads a value up to the end of the line or an inline comment
+func (p *envParser) readUnquoted() (string, error) {
+	start := p.pos
+	end := start
+	for ; end < len(p.src) && p.src[end] != '\n'; end++ {
+		if p.src[end] == '#' && (end == start || p.src[end-1] == ' ' || p.src[end-1] == '\t') {
+			break
+		}
+	}
+	raw := strings.TrimSpace(p.src[start:end])
+	p.pos = end
+	p.skipLine()
+	b := new(strings.Builder)
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '$' {
+			b.WriteByte(raw[i])
+			continue
+		}
+		expanded, next, err := p.expand(raw, i)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expanded)
+		i = next - 1
+	}
+	return b.String(), nil
+}
 
-		envMap[key] = value
+// readQuoted reads a single or double quoted value, which can span lines
+func (p *envParser) readQuoted() (string, error) {
+	quote := p.src[p.pos]
+	startLine := p.line
+	p.pos++
+	b := new(strings.Builder)
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == quote:
+			p.pos++
+			return b.String(), nil
+		case c == '\n':
+			p.line++
+		case quote == '"' && c == '\\' && p.pos+1 < len(p.src):
+			p.pos++
+			switch e := p.src[p.pos]; e {
+			case 'n':
+				b.WriteByte('\n')
+			case 'r':
+				b.WriteByte('\r')
+			case 't':
+				b.WriteByte('\t')
+			case '\\', '"', '$':
+				b.WriteByte(e)
+			default:
+				b.WriteByte('\\')
+				b.WriteByte(e)
+			}
+			p.pos++
+			continue
+		case quote == '"' && c == '$':
+			expanded, next, err := p.expand(p.src, p.pos)
+			if err != nil {
+				return "", err
+			}
+			b.WriteString(expanded)
+			p.pos = next
+			continue
+		}
+		b.WriteByte(c)
+		p.pos++
 	}
+	return "", fmt.Errorf("unterminated quoted value starting on line %v", startLine)
+}
 
-	return envMap, nil
+// expand expands the variable starting with the $ at s[i], it returns the value and the index after the variable
+func (p *envParser) expand(s string, i int) (string, int, error) {
+	if i+1 < len(s) && s[i+1] == '{' {
+		end := strings.IndexByte(s[i:], '}')
+		if end < 0 {
+			return "", 0, fmt.Errorf("unterminated ${ in %q", s[i:])
+		}
+		end += i
+		expr := s[i+2 : end]
+		name := envVarName.FindString(expr)
+		op := strings.TrimPrefix(expr[len(name):], ":")
+		orEmpty := len(op) < len(expr)-len(name)
+		if name == "" || op != "" && !strings.ContainsRune("-?+", rune(op[0])) {
+			return "", 0, fmt.Errorf("invalid variable ${%v}", expr)
+		}
+		v, ok := p.lookup(name)
+		set := ok && (!orEmpty || v != "")
+		switch {
+		case op == "":
+		case op[0] == '-' && !set:
+			v = op[1:]
+		case op[0] == '?' && !set:
+			if op[1:] == "" {
+				return "", 0, fmt.Errorf("required variable %v is missing a value", name)
+			}
+			return "", 0, fmt.Errorf("required variable %v is missing a value : %v", name, op[1:])
+		case op[0] == '+':
+			v = ""
+			if set {
+				v = op[1:]
+			}
+		}
+		return v, end + 1, nil
+	}
+	end := i + 1
+	for end < len(s) && (s[end] == '_' || 'a' <= s[end] && s[end] <= 'z' || 'A' <= s[end] && s[end] <= 'Z' || end > i+1 && '0' <= s[end] && s[end] <= '9') {
+		end++
+	}
+	if end == i+1 {
+		// not a variable, a lone $
+		return "$", end, nil
+	}
+	v, _ := p.lookup(s[i+1 : end])
+	return v, end, nil
+}
+
+// lookup returns the value of the variable name defined above in the content or in the environment
+func (p *envParser) lookup(name string) (string, bool) {
+	if v, ok := p.vars[name]; ok {
+		return v, true
+	}
+	return p.lookupEnv(name)
 }
 
 // MapToYAML writes a map to a YAML string
@@ -111,34 +357,436 @@ func TOMLToMap(content string) (map[string]interface{}, error) {
 	return config, nil
 }
 
-/* // ReadProperties reads the contents of a Property file from a string and returns a map[string]string
-func ReadProperties(content string) (map[string]interface{}, error) {
+// PropertiesToMap reads the contents of a Java style .properties file from a string and returns a map,
+// dotted keys are nested so server.port=80 becomes {"server": {"port": "80"}}.
+// keys and values are separated by =, : or white space, lines starting with # or ! are comments,
+// a line ending with a backslash continues on the next line and escapes like \t, \n and \uXXXX are supported.
+func PropertiesToMap(content string) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
-	lines := strings.Split(content, "\n")
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if len(line) == 0 || line[0] == '#' {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i := 0; i < len(lines); i++ {
+		lineNumber := i + 1
+		line := strings.TrimLeft(lines[i], " \t\f")
+		if line == "" || line[0] == '#' || line[0] == '!' {
 			continue
-	result2 := make(map[string]interface{})
-	for k, v := range result {
-		result2[k] = v
+		}
+		for endsWithContinuation(line) {
+			line = line[:len(line)-1]
+			if i+1 == len(lines) {
+				break
+			}
+			i++
+			line += strings.TrimLeft(lines[i], " \t\f")
+		}
+		rawKey, rawValue := splitProperty(line)
+		key, err := unescapeProperty(rawKey)
+		if err != nil {
+			return nil, fmt.Errorf("invalid key on line %v: %v", lineNumber, err)
+		}
+		value, err := unescapeProperty(rawValue)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value on line %v: %v", lineNumber, err)
+		}
+		result, err = setValueByDotNotation(result, key, value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to set key %v on line %v: %v", key, lineNumber, err)
+		}
 	}
+	return result, nil
+}
+
+// endsWithContinuation reports whether line ends with an odd number of backslashes
+func endsWithContinuation(line string) bool {
+	n := 0
+	for i := len(line) - 1; i >= 0 && line[i] == '\\'; i-- {
+		n++
+	}
+	return n%2 == 1
+}
+
+// splitProperty splits a property line at the first unescaped =, : or white space
+func splitProperty(line string) (key string, value string) {
+	for i := 0; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '=', ':':
+			return line[:i], strings.TrimLeft(line[i+1:], " \t\f")
+		case ' ', '\t', '\f':
+			rest := strings.TrimLeft(line[i:], " \t\f")
+			if rest != "" && (rest[0] == '=' || rest[0] == ':') {
+				rest = strings.TrimLeft(rest[1:], " \t\f")
+			}
+			return line[:i], rest
 		}
-		parts :2= strings.Split(line, "#")
-		keyValue := strings.TrimSpace(parts[0])
-		if len(keyValue) == 0 {
+	}
+	return line, ""
+}
+
+// unescapeProperty resolves the escapes of a property key or value
+func unescapeProperty(s string) (string, error) {
+	if !strings.Contains(s, "\\") {
+		return s, nil
+	}
+	b := new(strings.Builder)
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			b.WriteByte(s[i])
 			continue
 		}
-		parts = strings.SplitN(keyValue, "=", 2)
-		if len(parts) == 2 {
-			key := strings.TrimSpace(parts[0])
-			value := strings.TrimSpace(parts[1])
-			result[key] = value
+		i++
+		switch s[i] {
+		case 't':
+			b.WriteByte('\t')
+		case 'n':
+			b.WriteByte('\n')
+		case 'r':
+			b.WriteByte('\r')
+		case 'f':
+			b.WriteByte('\f')
+		case 'u':
+			if i+5 > len(s) {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			r, err := strconv.ParseUint(s[i+1:i+5], 16, 16)
+			if err != nil {
+				return "", fmt.Errorf("malformed \\u escape in %q", s)
+			}
+			b.WriteRune(rune(r))
+			i += 4
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+	return b.String(), nil
+}
+
+// MapToProperties writes a map to a .properties string, nested maps are written as dotted keys
+// and lists as comma separated values.
+func MapToProperties(data map[string]interface{}) (string, error) {
+	flat := make(map[string]string)
+	err := flattenCfg(data, "", flat)
+	if err != nil {
+		return "", fmt.Errorf("unable to map to properties : %v", err)
+	}
+	b := new(strings.Builder)
+	for _, key := range sortedKeys(flat) {
+		fmt.Fprintf(b, "%v=%v\n", escapeProperty(key, true), escapeProperty(flat[key], false))
+	}
+	return b.String(), nil
+}
+
+// escapeProperty escapes a property key or value so PropertiesToMap reads it back as it is
+func escapeProperty(s string, isKey bool) string {
+	b := new(strings.Builder)
+	for i, r := range s {
+		switch {
+		case r == '\\':
+			b.WriteString("\\\\")
+		case r == '\n':
+			b.WriteString("\\n")
+		case r == '\r':
+			b.WriteString("\\r")
+		case r == '\t':
+			b.WriteString("\\t")
+		case r == '\f':
+			b.WriteString("\\f")
+		case isKey && (r == '=' || r == ':' || r == ' '):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case i == 0 && (r == ' ' || r == '#' || r == '!'):
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		default:
+			b.WriteRune(r)
 		}
 	}
+	return b.String()
+}
 
+// INIToMap reads the contents of an INI file from a string and returns a map,
+// a [section] is the first element of the dot notation of its keys, so port under [server] is server.port.
+// lines starting with ; or # are comments, so is a ; or # after a white space or a quoted value, and quoted values are unquoted.
+func INIToMap(content string) (map[string]interface{}, error) {
+	result := make(map[string]interface{})
+	section := ""
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("invalid section on line %v: %v", i+1, line)
+			}
+			section = strings.TrimSpace(line[1:len(line)-1]) + "."
+			continue
+		}
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid line %v: %v", i+1, line)
+		}
+		key := strings.TrimSpace(k)
+		value := stripINIComment(strings.TrimSpace(v))
+		if len(value) > 1 && value[0] == '"' && value[len(value)-1] == '"' {
+			unquoted, err := strconv.Unquote(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid quoted value on line %v: %v", i+1, err)
+			}
+			value = unquoted
+		} else if len(value) > 1 && value[0] == '\'' && value[len(value)-1] == '\'' {
+			value = value[1 : len(value)-1]
+		}
+		var err error
+		result, err = setValueByDotNotation(result, section+key, value)
+		if err != nil {
+			return nil, fmt.Errorf("unable to set key %v on line %v: %v", section+key, i+1, err)
+		}
+	}
 	return result, nil
-} */
+}
+
+// stripINIComment returns value without the ; or # comment following it, a comment starts after a white space
+// or after the closing quote of a quoted value
+func stripINIComment(value string) string {
+	end := -1
+	switch {
+	case strings.HasPrefix(value, `"`):
+		if quoted, err := strconv.QuotedPrefix(value); err == nil {
+			end = len(quoted)
+		}
+	case strings.HasPrefix(value, "'"):
+		if i := strings.IndexByte(value[1:], '\''); i >= 0 {
+			end = i + 2
+		}
+	}
+	if end >= 0 {
+		if rest := strings.TrimSpace(value[end:]); rest == "" || rest[0] == ';' || rest[0] == '#' {
+			return value[:end]
+		}
+		return value
+	}
+	for i := 1; i < len(value); i++ {
+		if (value[i] == ';' || value[i] == '#') && (value[i-1] == ' ' || value[i-1] == '\t') {
+			return strings.TrimSpace(value[:i])
+		}
+	}
+	return value
+}
+
+// MapToINI writes a map to an INI string, the top level tables are written as sections,
+// deeper tables as dotted keys and lists as comma separated values.
+func MapToINI(data map[string]interface{}) (string, error) {
+	m, err := stringMap(data)
+	if err != nil {
+		return "", fmt.Errorf("unable to map to INI : %v", err)
+	}
+	b := new(strings.Builder)
+	var sections []string
+	for _, key := range sortedKeys(m) {
+		if isTable(m[key]) {
+			sections = append(sections, key)
+			continue
+		}
+		value, err := flatValue(m[key])
+		if err != nil {
+			return "", fmt.Errorf("unable to map to INI : %v", err)
+		}
+		fmt.Fprintf(b, "%v = %v\n", key, quoteINI(value))
+	}
+	for _, section := range sections {
+		flat := make(map[string]string)
+		err := flattenCfg(m[section], "", flat)
+		if err != nil {
+			return "", fmt.Errorf("unable to map to INI : %v", err)
+		}
+		if b.Len() > 0 {
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(b, "[%v]\n", section)
+		for _, key := range sortedKeys(flat) {
+			fmt.Fprintf(b, "%v = %v\n", key, quoteINI(flat[key]))
+		}
+	}
+	return b.String(), nil
+}
+
+// quoteINI quotes an INI value which would not be read back as it is
+func quoteINI(value string) string {
+	if value != strings.TrimSpace(value) || strings.ContainsAny(value, "\"'\n\r;#") {
+		return strconv.Quote(value)
+	}
+	return value
+}
+
+// HCLToMap reads the contents of an HCL file from a string and returns a map, blocks are read as tables
+// and repeated blocks with the same name are merged.
+func HCLToMap(content string) (map[string]interface{}, error) {
+	var result map[string]interface{}
+	err := hcl.Decode(&result, content)
+	if err != nil {
+		return nil, err
+	}
+	return mergeHCLBlocks(result).(map[string]interface{}), nil
+}
+
+// mergeHCLBlocks turns the list of maps HCL decodes every block to into a single map
+func mergeHCLBlocks(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, e := range v {
+			v[k] = mergeHCLBlocks(e)
+		}
+		return v
+	case []map[string]interface{}:
+		merged := make(map[string]interface{})
+		for _, block := range v {
+			for k, e := range block {
+				merged[k] = e
+			}
+		}
+		return mergeHCLBlocks(merged)
+	case []interface{}:
+		for i, e := range v {
+			v[i] = mergeHCLBlocks(e)
+		}
+		return v
+	}
+	return v
+}
+
+// MapToHCL writes a map to an HCL string, tables are written as blocks.
+func MapToHCL(data map[string]interface{}) (string, error) {
+	b := new(strings.Builder)
+	err := writeHCL(b, data, "")
+	if err != nil {
+		return "", fmt.Errorf("unable to map to HCL : %v", err)
+	}
+	return b.String(), nil
+}
+
+var hclIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*$`)
+
+func writeHCL(b *strings.Builder, data interface{}, indent string) error {
+	m, err := stringMap(data)
+	if err != nil {
+		return err
+	}
+	var blocks []string
+	for _, key := range sortedKeys(m) {
+		if isTable(m[key]) {
+			blocks = append(blocks, key)
+			continue
+		}
+		value, err := hclValue(m[key])
+		if err != nil {
+			return fmt.Errorf("key %v: %v", key, err)
+		}
+		fmt.Fprintf(b, "%v%v = %v\n", indent, hclKey(key), value)
+	}
+	for _, key := range blocks {
+		fmt.Fprintf(b, "%v%v {\n", indent, hclKey(key))
+		err := writeHCL(b, m[key], indent+"  ")
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(b, "%v}\n", indent)
+	}
+	return nil
+}
+
+func hclKey(key string) string {
+	if hclIdentifier.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+func hclValue(v interface{}) (string, error) {
+	switch v := v.(type) {
+	case nil:
+		return `""`, nil
+	case string:
+		return strconv.Quote(v), nil
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		values := make([]string, len(v))
+		for i, e := range v {
+			value, err := hclValue(e)
+			if err != nil {
+				return "", err
+			}
+			values[i] = value
+		}
+		return "[" + strings.Join(values, ", ") + "]", nil
+	}
+	return "", fmt.Errorf("unsupported value %v of type %T", v, v)
+}
+
+// flattenCfg sets every value of data into flat by its dot notation under prefix, lists are comma separated
+func flattenCfg(data interface{}, prefix string, flat map[string]string) error {
+	m, err := stringMap(data)
+	if err != nil {
+		return err
+	}
+	for key, v := range m {
+		if isTable(v) {
+			err := flattenCfg(v, prefix+key+".", flat)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		value, err := flatValue(v)
+		if err != nil {
+			return fmt.Errorf("key %v: %v", prefix+key, err)
+		}
+		flat[prefix+key] = value
+	}
+	return nil
+}
+
+// flatValue returns a value which is not a table as a string, lists are comma separated the way slice flags read them
+func flatValue(v interface{}) (string, error) {
+	list, ok := v.([]interface{})
+	if !ok {
+		return jsonnify(v)
+	}
+	values := make([]string, len(list))
+	for i, e := range list {
+		if isTable(e) {
+			return "", fmt.Errorf("a list of tables can't be written as a value")
+		}
+		values[i] = fmt.Sprint(e)
+	}
+	b := new(strings.Builder)
+	w := csv.NewWriter(b)
+	err := w.Write(values)
+	if err != nil {
+		return "", err
+	}
+	w.Flush()
+	return strings.TrimSuffix(b.String(), "\n"), nil
+}
+
+func isTable(v interface{}) bool {
+	switch v.(type) {
+	case map[string]interface{}, map[interface{}]interface{}:
+		return true
+	}
+	return false
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
 
 func jsonnify(v interface{}) (string, error) {
 	if v == nil {
@@ -155,31 +803,79 @@ func jsonnify(v interface{}) (string, error) {
 	return fmt.Sprintf("%v", v), nil
 }
 
-func getValueByNotationArray(inputMap map[string]interface{}, notation []string) (s string, err error) {
+func getRawValueByNotationArray(inputMap map[string]interface{}, notation []string) (v interface{}, err error) {
 	data, err := stringMap(inputMap)
 	if err != nil {
-		return "", fmt.Errorf("unable to get value by dot notation : %v", err)
+		return nil, fmt.Errorf("unable to get value by dot notation : %v", err)
 	}
 	key := notation[0]
 	notation = notation[1:]
 	nextData := data[key]
 	if len(notation) == 0 {
-		return jsonnify(nextData)
+		return nextData, nil
 	}
 	if nextMap, ok := nextData.(map[string]interface{}); ok {
 		//value is a map, go recursive
-		v, err := getValueByNotationArray(nextMap, notation)
+		v, err := getRawValueByNotationArray(nextMap, notation)
 		if err != nil {
-			return "", fmt.Errorf("unable to get value by dot notation : %v", err)
+			return nil, fmt.Errorf("unable to get value by dot notation : %v", err)
 		}
 		return v, nil
 	}
-	return "", fmt.Errorf("value not found for dot notation")
+	return nil, fmt.Errorf("value not found for dot notation")
 }
 
 func getValueByDotNotation(inputMap map[string]interface{}, not string) (s string, err error) {
+	v, err := getRawValueByDotNotation(inputMap, not)
+	if err != nil {
+		return "", err
+	}
+	return jsonnify(v)
+}
+
+// getRawValueByDotNotation returns the value as it is in the config, lists and tables are not jsonnified
+func getRawValueByDotNotation(inputMap map[string]interface{}, not string) (v interface{}, err error) {
 	sNotation := strings.Split(not, ".")
-	return getValueByNotationArray(inputMap, sNotation)
+	return getRawValueByNotationArray(inputMap, sNotation)
+}
+
+// mergeCfg deep merges src into dst, tables present in both are merged and any other value of src overrides the one in dst.
+// every key taken from src is recorded in origins as coming from file, by its dot notation under prefix
+func mergeCfg(dst map[string]interface{}, src map[string]interface{}, prefix string, file string, origins map[string]string) error {
+	src, err := stringMap(src)
+	if err != nil {
+		return err
+	}
+	for key, value := range src {
+		notation := prefix + key
+		srcMap, srcIsMap := value.(map[string]interface{})
+		dstMap, dstIsMap := dst[key].(map[string]interface{})
+		if srcIsMap && dstIsMap {
+			err := mergeCfg(dstMap, srcMap, notation+".", file, origins)
+			if err != nil {
+				return err
+			}
+			origins[notation] = file
+			continue
+		}
+		// the whole value is replaced, forget where the keys under it came from
+		for k := range origins {
+			if strings.HasPrefix(k, notation+".") {
+				delete(origins, k)
+			}
+		}
+		if srcIsMap {
+			copied := make(map[string]interface{}, len(srcMap))
+			err := mergeCfg(copied, srcMap, notation+".", file, origins)
+			if err != nil {
+				return err
+			}
+			value = copied
+		}
+		dst[key] = value
+		origins[notation] = file
+	}
+	return nil
 }
 
 func stringMap(inputMap interface{}) (map[string]interface{}, error) {
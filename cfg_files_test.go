@@ -31,6 +31,92 @@ KEY4 = Value4 = ExtraValue`
 	}
 }
 
+func TestEnvToMap_Dotenv(t *testing.T) {
+	t.Setenv("TEST_DOTENV_HOME", "/home/me")
+	t.Setenv("TEST_DOTENV_EMPTY", "")
+	t.Setenv("TEST_DOTENV_PASSED", "from env")
+	content := "# comment\n" +
+		"export EXPORTED=yes\n" +
+		"  INDENTED = value   # inline comment\n" +
+		"HASH=a#b\n" +
+		"EMPTY=\n" +
+		"EMPTY_COMMENT= # nothing\n" +
+		"SINGLE='literal $HOME \\n # not a comment'\n" +
+		"DOUBLE=\"tab\\there\\nnew \\\"quoted\\\" \\$HOME\" # comment\n" +
+		"MULTI=\"first\nsecond\"\n" +
+		"SINGLE_MULTI='first\nsecond'\n" +
+		"PATH_VAR=${TEST_DOTENV_HOME}/bin:$TEST_DOTENV_HOME\n" +
+		"REF=\"$EXPORTED-${INDENTED}\"\n" +
+		"DEF=${TEST_DOTENV_MISSING:-fallback}\n" +
+		"DEF_EMPTY=${TEST_DOTENV_EMPTY:-fallback}\n" +
+		"DEF_UNSET=${TEST_DOTENV_EMPTY-fallback}\n" +
+		"ALT=${TEST_DOTENV_HOME:+set}\n" +
+		"ALT_EMPTY=${TEST_DOTENV_EMPTY:+set}\n" +
+		"ALT_UNSET=${TEST_DOTENV_EMPTY+set}\n" +
+		"REQUIRED=${TEST_DOTENV_HOME:?home is required}\n" +
+		"DOLLAR=cost $5\n" +
+		"TEST_DOTENV_PASSED\n" +
+		"TEST_DOTENV_UNSET\n" +
+		"CRLF=value\r\n"
+	expected := map[string]string{
+		"EXPORTED":           "yes",
+		"INDENTED":           "value",
+		"HASH":               "a#b",
+		"EMPTY":              "",
+		"EMPTY_COMMENT":      "",
+		"SINGLE":             `literal $HOME \n # not a comment`,
+		"DOUBLE":             "tab\there\nnew \"quoted\" $HOME",
+		"MULTI":              "first\nsecond",
+		"SINGLE_MULTI":       "first\nsecond",
+		"PATH_VAR":           "/home/me/bin:/home/me",
+		"REF":                "yes-value",
+		"DEF":                "fallback",
+		"DEF_EMPTY":          "fallback",
+		"DEF_UNSET":          "",
+		"ALT":                "set",
+		"ALT_EMPTY":          "",
+		"ALT_UNSET":          "set",
+		"REQUIRED":           "/home/me",
+		"DOLLAR":             "cost $5",
+		"TEST_DOTENV_PASSED": "from env",
+		"CRLF":               "value",
+	}
+	result, err := EnvToMap(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		for k, v := range expected {
+			if result[k] != v {
+				t.Errorf("expected %v to be %q but got %q", k, v, result[k])
+			}
+		}
+		for k := range result {
+			if _, ok := expected[k]; !ok {
+				t.Errorf("unexpected key %v", k)
+			}
+		}
+	}
+
+	errs := map[string]string{
+		"A=1\nB=\"unterminated\n\n":        "line 2: unterminated quoted value starting on line 2",
+		"A=1\n\n1BAD=x":                    "line 3: invalid key \"1BAD\"",
+		"A='x' trailing":                   "line 1: unexpected 't' after the quoted value of A",
+		"A=1\nB=${UNTERMINATED\n":          "line 2: unterminated ${ in \"${UNTERMINATED\"",
+		"A=1\nKEY value":                   "line 2: expected = after key KEY",
+		"A=${TEST_DOTENV_MISSING:?set it}": "line 1: required variable TEST_DOTENV_MISSING is missing a value : set it",
+		"A=${TEST_DOTENV_EMPTY:?}":         "line 1: required variable TEST_DOTENV_EMPTY is missing a value",
+		"A=${B.C}":                         "line 1: invalid variable ${B.C}",
+		"A=${B:=x}":                        "line 1: invalid variable ${B:=x}",
+	}
+	for content, want := range errs {
+		_, err := EnvToMap(content)
+		if err == nil || err.Error() != want {
+			t.Errorf("expected error %q for %q but got %v", want, content, err)
+		}
+	}
+}
+
 func Test_jsonnify(t *testing.T) {
 	type args struct {
 		v interface{}
@@ -374,3 +460,151 @@ func TestAddValueByDotNotation(t *testing.T) {
 	}
 
 }
+
+func TestPropertiesToMap(t *testing.T) {
+	content := `# comment
+! another comment
+server.host = example.com
+server.port:8080
+name   turbo flag
+path=C:\\dir\\file
+multi=one \
+      two
+unicode=caf\u00e9
+key\ with\ spaces=value
+empty=
+`
+	expected := map[string]interface{}{
+		"server": map[string]interface{}{
+			"host": "example.com",
+			"port": "8080",
+		},
+		"name":            "turbo flag",
+		"path":            `C:\dir\file`,
+		"multi":           "one two",
+		"unicode":         "café",
+		"key with spaces": "value",
+		"empty":           "",
+	}
+	result, err := PropertiesToMap(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v but got %v", expected, result)
+	}
+	if _, err := PropertiesToMap(`bad=\u12`); err == nil {
+		t.Error("expected an error for a malformed \\u escape")
+	}
+}
+
+func TestINIToMap(t *testing.T) {
+	content := `; comment
+level = info
+
+[server]
+host = example.com
+port = 8080 ; http
+# comment
+tls.cert = "a b.pem" # inline comment
+url = http://example.com/#top
+
+[database]
+name = 'main' ; primary
+`
+	expected := map[string]interface{}{
+		"level": "info",
+		"server": map[string]interface{}{
+			"host": "example.com",
+			"port": "8080",
+			"tls":  map[string]interface{}{"cert": "a b.pem"},
+			"url":  "http://example.com/#top",
+		},
+		"database": map[string]interface{}{"name": "main"},
+	}
+	result, err := INIToMap(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %v but got %v", expected, result)
+	}
+	if _, err := INIToMap("[server\nport = 1"); err == nil {
+		t.Error("expected an error for an unclosed section")
+	}
+	if _, err := INIToMap("[server]\nport"); err == nil {
+		t.Error("expected an error for a line without =")
+	}
+}
+
+func TestHCLToMap(t *testing.T) {
+	content := `
+level = "info"
+ports = [80, 443]
+server {
+  host = "example.com"
+}
+server {
+  tls { cert = "a.pem" }
+}
+`
+	result, err := HCLToMap(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]interface{}{
+		"level": "info",
+		"ports": []interface{}{80, 443},
+		"server": map[string]interface{}{
+			"host": "example.com",
+			"tls":  map[string]interface{}{"cert": "a.pem"},
+		},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("expected %#v but got %#v", expected, result)
+	}
+}
+
+func TestMapToFormats_RoundTrip(t *testing.T) {
+	data := map[string]interface{}{
+		"level": "info",
+		"tags":  []interface{}{"a", "b,c"},
+		"server": map[interface{}]interface{}{
+			"host": " spaced ",
+			"port": 8080,
+			"tls":  map[string]interface{}{"cert": "a=b.pem"},
+		},
+	}
+	tests := []struct {
+		name  string
+		write func(map[string]interface{}) (string, error)
+		read  func(string) (map[string]interface{}, error)
+	}{
+		{"properties", MapToProperties, PropertiesToMap},
+		{"ini", MapToINI, INIToMap},
+		{"hcl", MapToHCL, HCLToMap},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := tt.write(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			result, err := tt.read(content)
+			if err != nil {
+				t.Fatalf("unable to read back\n%v\n%v", content, err)
+			}
+			for notation, want := range map[string]string{
+				"level":           "info",
+				"server.host":     " spaced ",
+				"server.port":     "8080",
+				"server.tls.cert": "a=b.pem",
+			} {
+				got, err := getValueByDotNotation(result, notation)
+				if err != nil || got != want {
+					t.Errorf("expected %v to be %q but got %q (%v) from\n%v", notation, want, got, err, content)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package flag
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"strings"
+)
+
+// LoadCfgFrom loads the config read from r in format to this command tree, like LoadCfg,
+// an empty format sniffs it from the content, see SniffFormat.
+// a config loaded from a reader has no file, so it is not watched by WatchCfg.
+func (f *Command) LoadCfgFrom(r io.Reader, format Format) error {
+	return f.LoadCfgFromNamed(r, format, "")
+}
+
+// LoadCfgFromNamed is LoadCfgFrom with the name the config is reported from by CfgPath and CfgOrigin, like stdin.
+func (f *Command) LoadCfgFromNamed(r io.Reader, format Format, name string) error {
+	if f.parentCmd != nil {
+		return f.parentCmd.LoadCfgFromNamed(r, format, name)
+	}
+	b, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read config : %v", err)
+	}
+	if format == "" {
+		format, err = SniffFormat(b)
+		if err != nil {
+			return err
+		}
+	}
+	content, err := cfgToMap(string(b), format)
+	if err != nil {
+		return fmt.Errorf("unable to read config : %v", err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.setCfg([]string{name}, []map[string]interface{}{content}, nil, nil)
+}
+
+// LoadCfgFS loads the config files at paths of fsys (like an embed.FS) to this command tree and deep merges them, like LoadCfgs.
+// the format is the one of the extension, or sniffed from the content if there is none.
+// the files are not watched by WatchCfg.
+func (f *Command) LoadCfgFS(fsys fs.FS, paths ...string) error {
+	if f.parentCmd != nil {
+		return f.parentCmd.LoadCfgFS(fsys, paths...)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no path passed while loading config")
+	}
+	contents := make([]map[string]interface{}, len(paths))
+	for i, path := range paths {
+		b, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return fmt.Errorf("failed to read config file at %v : %v", path, err)
+		}
+		contents[i], err = decodeCfgFile(path, b)
+		if err != nil {
+			return err
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.setCfg(paths, contents, nil, nil)
+}
+
+// decodeCfgFile decodes b read from the file at path in the format of its extension,
+// or in the format sniffed from b if it has no extension
+func decodeCfgFile(path string, b []byte) (content map[string]interface{}, err error) {
+	var format Format
+	if filepath.Ext(path) == "" {
+		format, err = SniffFormat(b)
+	} else {
+		format, err = FormatOf(path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %v : %v", path, err)
+	}
+	content, err = cfgToMap(string(b), format)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read config file : %v", err)
+	}
+	return content, nil
+}
+
+// sniffOrder is the order SniffFormat tries the formats in, stricter formats first
+var sniffOrder = []Format{FormatTOML, FormatYAML, FormatHCL, FormatINI, FormatProperties}
+
+// SniffFormat returns the format of a config from its content, valid JSON is JSON,
+// otherwise the first of TOML, YAML, HCL, INI and properties which can read it.
+// flat key = value lines with bare values are properties, TOML needs a [table] or a quoted, array or inline table value,
+// HCL a block or such a value and INI a [section].
+func SniffFormat(content []byte) (Format, error) {
+	if json.Valid(content) {
+		return FormatJSON, nil
+	}
+	sections, blocks, typedValues := sniffLines(string(content))
+	for _, format := range sniffOrder {
+		switch {
+		case format == FormatTOML && !sections && !typedValues,
+			format == FormatHCL && !blocks && !typedValues,
+			format == FormatINI && !sections:
+			continue
+		}
+		if _, err := cfgToMap(string(content), format); err == nil {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unable to detect the format of the config")
+}
+
+// sniffLines reports whether content has [section] lines, lines opening a block,
+// and key = value lines whose value is quoted, an array or an inline table
+func sniffLines(content string) (sections bool, blocks bool, typedValues bool) {
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			sections = true
+			continue
+		}
+		if strings.HasSuffix(line, "{") {
+			blocks = true
+		}
+		i := strings.Index(line, "=")
+		if i < 0 {
+			continue
+		}
+		value := strings.TrimSpace(line[i+1:])
+		if value != "" && strings.ContainsAny(value[:1], `"'[{`) {
+			typedValues = true
+		}
+	}
+	return sections, blocks, typedValues
+}
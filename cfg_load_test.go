@@ -0,0 +1,88 @@
+package flag_test
+
+import (
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestSniffFormat(t *testing.T) {
+	tests := map[string]Format{
+		`{"server": {"port": 80}}`:              FormatJSON,
+		"[server]\nport = 80\n":                 FormatTOML,
+		"server:\n  port: 80\n":                 FormatYAML,
+		"server {\n  port = 80\n}\n":            FormatHCL,
+		"; comment\n[server]\nport = 80\n":      FormatINI,
+		"server.port=80\nserver.host example\n": FormatProperties,
+		"# comment\nserver.port : 80\nname=x\n": FormatProperties,
+		"server.port = 80\nserver.host = x\n":   FormatProperties,
+		"server.port = 80\ntags = [\"a\"]\n":    FormatTOML,
+	}
+	for content, want := range tests {
+		got, err := SniffFormat([]byte(content))
+		if err != nil {
+			t.Errorf("unexpected error sniffing %q : %v", content, err)
+			continue
+		}
+		if got != want {
+			t.Errorf("expected %q to be sniffed as %v but got %v", content, want, got)
+		}
+	}
+}
+
+func TestLoadCfgFrom(t *testing.T) {
+	for _, content := range []string{
+		`{"server": {"port": 80}}`,
+		"server:\n  port: 80\n",
+		"[server]\nport = 80\n",
+		"server.port=80\n",
+		"server.port = 80\nname = hello world\n",
+	} {
+		fs := OneCmd("test", ContinueOnError)
+		port := fs.Int("port", 0, "", fs.Cfg("server.port"))
+		err := fs.LoadCfgFrom(strings.NewReader(content), "")
+		if err != nil {
+			t.Fatal(err)
+		}
+		if *port != 80 {
+			t.Errorf("expected port 80 from %q but got %v", content, *port)
+		}
+	}
+	fs := OneCmd("test", ContinueOnError)
+	err := fs.LoadCfgFrom(strings.NewReader("server:\n  port: 80\n"), FormatJSON)
+	if err == nil {
+		t.Error("expected an error reading YAML as JSON")
+	}
+	err = fs.LoadCfgFromNamed(strings.NewReader("server.port = 80\n"), FormatTOML, "stdin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fs.CfgPath() != "stdin" || fs.CfgOrigin("server.port") != "stdin" {
+		t.Errorf("expected the config to come from stdin but got %q and %q", fs.CfgPath(), fs.CfgOrigin("server.port"))
+	}
+}
+
+func TestLoadCfgFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"defaults/app.yaml": {Data: []byte("server:\n  host: localhost\n  port: 80\n")},
+		"override":          {Data: []byte("[server]\nport = 8080\n")},
+	}
+	fs := OneCmd("test", ContinueOnError)
+	host := fs.String("host", "", "", fs.Cfg("server.host"))
+	port := fs.Int("port", 0, "", fs.Cfg("server.port"))
+	err := fs.LoadCfgFS(fsys, "defaults/app.yaml", "override")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *host != "localhost" || *port != 8080 {
+		t.Errorf("expected host localhost and port 8080 but got %v and %v", *host, *port)
+	}
+	if got := fs.CfgOrigin("server.port"); got != "override" {
+		t.Errorf("expected server.port to come from override but got %q", got)
+	}
+	if err := fs.LoadCfgFS(fsys, "missing.yaml"); err == nil {
+		t.Error("expected an error for a missing file")
+	}
+}
@@ -0,0 +1,167 @@
+package flag
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Format is the format of a config file.
+type Format string
+
+const (
+	FormatJSON       Format = "json"
+	FormatYAML       Format = "yaml"
+	FormatTOML       Format = "toml"
+	FormatProperties Format = "properties"
+	FormatINI        Format = "ini"
+	FormatHCL        Format = "hcl"
+)
+
+// FormatOf returns the format of the config file at path by its extension.
+func FormatOf(path string) (Format, error) {
+	ext := strings.ToUpper(filepath.Ext(path))
+	switch ext {
+	case "":
+		return "", fmt.Errorf("config file has no extension, add a supported extension [YAML,YML,JSON,TOML,PROPERTIES,INI,HCL]")
+	case ".JSON":
+		return FormatJSON, nil
+	case ".YML", ".YAML":
+		return FormatYAML, nil
+	case ".TOML":
+		return FormatTOML, nil
+	case ".PROPERTIES":
+		return FormatProperties, nil
+	case ".INI":
+		return FormatINI, nil
+	case ".HCL":
+		return FormatHCL, nil
+	}
+	return "", fmt.Errorf("unsupported extension %v", ext)
+}
+
+// cfgToMap reads content in format
+func cfgToMap(content string, format Format) (map[string]interface{}, error) {
+	switch format {
+	case FormatJSON:
+		return JSONToMap(content)
+	case FormatYAML:
+		return YAMLToMap(content)
+	case FormatTOML:
+		return TOMLToMap(content)
+	case FormatProperties:
+		return PropertiesToMap(content)
+	case FormatINI:
+		return INIToMap(content)
+	case FormatHCL:
+		return HCLToMap(content)
+	}
+	return nil, fmt.Errorf("unsupported config format %v", format)
+}
+
+// mapToCfg writes data in format
+func mapToCfg(data map[string]interface{}, format Format) (string, error) {
+	switch format {
+	case FormatJSON:
+		return MapToJSON(data)
+	case FormatYAML:
+		return MapToYAML(data)
+	case FormatTOML:
+		return MapToTOML(data)
+	case FormatProperties:
+		return MapToProperties(data)
+	case FormatINI:
+		return MapToINI(data)
+	case FormatHCL:
+		return MapToHCL(data)
+	}
+	return "", fmt.Errorf("unsupported config format %v", format)
+}
+
+// SaveCfg writes the effective cfg of the command and its sub commands to the file at path,
+// in the format of its extension, see WriteCfg.
+func (f *Command) SaveCfg(path string) error {
+	format, err := FormatOf(path)
+	if err != nil {
+		return err
+	}
+	content, err := f.effectiveCfg(format)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, []byte(content), 0644)
+	if err != nil {
+		return fmt.Errorf("unable to save config file at %v : %v", path, err)
+	}
+	return nil
+}
+
+// WriteCfg writes the current value of every flag bound to a cfg, of the command and all of its sub commands, to w in format,
+// under the first cfg key of the flag. handlers are not run, so the flags of a sub command added with SubCmd
+// are known only once its handler ran, it is an error naming the sub commands whose handler did not run yet,
+// declare the sub commands with NewSubCmd. the output can be loaded with LoadCfg, like a starter config file.
+func (f *Command) WriteCfg(w io.Writer, format Format) error {
+	content, err := f.effectiveCfg(format)
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, content)
+	return err
+}
+
+// effectiveCfg returns the cfg of f and its sub commands in format
+func (f *Command) effectiveCfg(format Format) (string, error) {
+	root := f.root()
+	root.mu.RLock()
+	data := map[string]interface{}{}
+	var skipped []string
+	err := f.collectCfgs(&data, &skipped)
+	root.mu.RUnlock()
+	if err != nil {
+		return "", err
+	}
+	if len(skipped) > 0 {
+		return "", fmt.Errorf("unable to write the cfg of the sub commands %v, their flags are defined by handlers which did not run, declare them with NewSubCmd", strings.Join(skipped, ", "))
+	}
+	content, err := mapToCfg(data, format)
+	if err != nil {
+		return "", fmt.Errorf("unable to write config as %v : %v", format, err)
+	}
+	return content, nil
+}
+
+// collectCfgs sets the cfg of f and of its sub commands with their flags defined into data,
+// the paths of the sub commands added with SubCmd whose handler did not run yet are appended to skipped
+func (f *Command) collectCfgs(data *map[string]interface{}, skipped *[]string) error {
+	err := f.collectCfg(data)
+	if err != nil {
+		return err
+	}
+	for _, sc := range f.visibleSubCmds() {
+		if !sc.ran {
+			*skipped = append(*skipped, sc.fs.path())
+			continue
+		}
+		err = sc.fs.collectCfgs(data, skipped)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectCfg sets the value of every flag of f bound to a cfg into data
+func (f *Command) collectCfg(data *map[string]interface{}) (err error) {
+	for _, flag := range sortFlags(f.formal) {
+		if flag.aliasFor != "" || len(flag.cfgList) == 0 {
+			continue
+		}
+		*data, err = setValueByDotNotation(*data, flag.cfgList[0], cfgValue(flag.Value))
+		if err != nil {
+			return fmt.Errorf("unable to set cfg %v of flag %v : %v", flag.cfgList[0], flag.Name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,76 @@
+package flag_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestWriteCfg(t *testing.T) {
+	t.Setenv("TEST_SAVE_PORT", "9000")
+	fs := OneCmd("test", ContinueOnError)
+	fs.Int("port", 80, "", fs.Cfg("server.port"), fs.Env("TEST_SAVE_PORT"), fs.Alias("p"))
+	fs.String("host", "localhost", "", fs.Cfg("server.host"))
+	fs.StringSlice("tag", []string{"a", "b"}, "", fs.Cfg("tags"))
+	fs.Bool("verbose", false, "not bound to a cfg")
+	serve := fs.NewSubCmd("serve", "", nil)
+	workers := serve.Int("workers", 4, "", serve.Cfg("serve.workers"))
+
+	b := new(bytes.Buffer)
+	err := fs.WriteCfg(b, FormatTOML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{`tags = ["a", "b"]`, `port = 9000`, `host = "localhost"`, `workers = 4`} {
+		if !strings.Contains(b.String(), line) {
+			t.Errorf("expected %q in\n%v", line, b.String())
+		}
+	}
+	if strings.Contains(b.String(), "verbose") {
+		t.Errorf("expected flags without a cfg to be skipped but got\n%v", b.String())
+	}
+
+	err = fs.Parse([]string{"serve", "--workers", "3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *workers != 3 {
+		t.Errorf("expected workers to be 3 but got %v", *workers)
+	}
+
+	for _, ext := range []string{"yaml", "json", "toml"} {
+		path := filepath.Join(t.TempDir(), "saved."+ext)
+		err = fs.SaveCfg(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		loaded := OneCmd("test", ContinueOnError)
+		err = loaded.LoadCfg(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		port := loaded.Int("port", 0, "", loaded.Cfg("server.port"))
+		tags := loaded.StringSlice("tag", nil, "", loaded.Cfg("tags"))
+		w := loaded.Int("workers", 0, "", loaded.Cfg("serve.workers"))
+		if *port != 9000 || !reflect.DeepEqual(*tags, []string{"a", "b"}) || *w != 3 {
+			t.Errorf("expected %v to load port 9000, tags [a b] and workers 3 but got %v, %v and %v", ext, *port, *tags, *w)
+		}
+	}
+
+	if err := fs.SaveCfg(filepath.Join(t.TempDir(), "saved.txt")); err == nil {
+		t.Error("expected an error for an unsupported extension")
+	}
+
+	fs.SubCmd("migrate", "", func(cmd Cmd, args []string) {
+		t.Error("expected WriteCfg not to run the handler")
+	})
+	fs.SubCmd("seed", "", func(cmd Cmd, args []string) {})
+	err = fs.WriteCfg(new(bytes.Buffer), FormatYAML)
+	if err == nil || !strings.Contains(err.Error(), "test migrate, test seed") {
+		t.Errorf("expected an error naming the sub commands whose flags are not defined but got %v", err)
+	}
+}
@@ -0,0 +1,141 @@
+package flag
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+)
+
+// CfgChange is a flag whose value changed because the watched config files changed.
+type CfgChange struct {
+	Cmd  string // name of the command the flag is defined on
+	Flag *Flag
+	Old  string // value before the reload, as text
+	New  string // value after the reload, as text
+}
+
+// OnCfgChange registers fn to be called by WatchCfg after every reload of the config files,
+// with the flags whose value changed, or with the error if the reload failed.
+// fn is called without holding the lock, so it can RLock to read the values.
+func (f *Command) OnCfgChange(fn func(changes []CfgChange, err error)) {
+	root := f.root()
+	root.mu.Lock()
+	defer root.mu.Unlock()
+	root.cfgCallbacks = append(root.cfgCallbacks, fn)
+}
+
+// RLock locks the flag values of the command tree for reading, WatchCfg waits for the readers
+// before applying a reload. goroutines reading the flags while WatchCfg is running should hold it,
+// reads without it race with a reload. keep it held only for the reads, and don't call Parse,
+// LoadCfg (or the other Load functions) while holding it, they lock the tree for writing and deadlock.
+func (f *Command) RLock() {
+	f.root().mu.RLock()
+}
+
+// RUnlock undoes a single RLock call.
+func (f *Command) RUnlock() {
+	f.root().mu.RUnlock()
+}
+
+// WatchCfg polls the loaded config files every interval and loads them again when any of them changes,
+// the flags of the command and all of its sub commands are resolved again, values passed in the arguments are kept.
+// a reload which fails keeps the values as they are and is retried once the files change again,
+// the callbacks registered with OnCfgChange are called after every reload. it blocks until ctx is done and returns ctx.Err().
+func (f *Command) WatchCfg(ctx context.Context, interval time.Duration) error {
+	root := f.root()
+	root.mu.RLock()
+	loaded := len(root.cfgPaths) > 0
+	root.mu.RUnlock()
+	if !loaded {
+		return fmt.Errorf("no config file is loaded to watch")
+	}
+	var failed []string // stamps of the files which failed to load, not retried until they change again
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		root.mu.RLock()
+		paths := root.cfgPaths
+		loadedStamps := root.cfgStamps
+		callbacks := root.cfgCallbacks
+		root.mu.RUnlock()
+		stamps := statCfgs(paths)
+		if equalStrings(stamps, loadedStamps) || equalStrings(stamps, failed) {
+			continue
+		}
+		changes, err := root.reloadCfg(paths)
+		failed = nil
+		if err != nil {
+			failed = stamps
+		}
+		if err == nil && len(changes) == 0 {
+			continue
+		}
+		for _, fn := range callbacks {
+			fn(changes, err)
+		}
+	}
+}
+
+// reloadCfg loads paths again holding the lock and returns the flags whose value changed
+func (f *Command) reloadCfg(paths []string) ([]CfgChange, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var before []CfgChange
+	f.snapshotValues(&before)
+	err := f.loadCfgs(paths)
+	if err != nil {
+		return nil, err
+	}
+	var changes []CfgChange
+	for _, c := range before {
+		c.New = c.Flag.Value.String()
+		if c.New != c.Old {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+// snapshotValues appends the current value of every flag of f and its sub commands, aliases are skipped
+func (f *Command) snapshotValues(values *[]CfgChange) {
+	for _, flag := range sortFlags(f.formal) {
+		if flag.aliasFor == "" {
+			*values = append(*values, CfgChange{Cmd: f.name, Flag: flag, Old: flag.Value.String()})
+		}
+	}
+	for _, sc := range f.visibleSubCmds() {
+		sc.fs.snapshotValues(values)
+	}
+}
+
+// statCfgs returns the modification time and size of every file in paths, to detect a change
+func statCfgs(paths []string) []string {
+	stamps := make([]string, len(paths))
+	for i, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			stamps[i] = err.Error()
+			continue
+		}
+		stamps[i] = fmt.Sprint(info.ModTime().UnixNano(), info.Size())
+	}
+	return stamps
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
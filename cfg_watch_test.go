@@ -0,0 +1,109 @@
+package flag_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestWatchCfg(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	err := os.WriteFile(path, []byte("level: info\nport: 80\nworkers: 1\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := OneCmd("test", ContinueOnError)
+	err = fs.LoadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	level := fs.String("level", "", "", fs.Cfg("level"))
+	port := fs.Int("port", 0, "", fs.Cfg("port"))
+	workers := fs.Int("workers", 0, "", fs.Cfg("workers"))
+	err = fs.Parse([]string{"--port", "9000"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reloaded := make(chan []CfgChange, 1)
+	fs.OnCfgChange(func(changes []CfgChange, err error) {
+		if err != nil {
+			t.Error(err)
+		}
+		reloaded <- changes
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- fs.WatchCfg(ctx, 10*time.Millisecond)
+	}()
+
+	err = os.WriteFile(path, []byte("level: debug\nport: 81\nworkers: 1\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// make sure the modification time changes even on coarse file systems
+	future := time.Now().Add(time.Hour)
+	err = os.Chtimes(path, future, future)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case changes := <-reloaded:
+		if len(changes) != 1 {
+			t.Fatalf("expected only level to change but got %v", changes)
+		}
+		c := changes[0]
+		if c.Flag.Name != "level" || c.Old != "info" || c.New != "debug" || c.Cmd != "test" {
+			t.Errorf("unexpected change %+v", c)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("config change was not detected")
+	}
+	fs.RLock()
+	if *level != "debug" || *port != 9000 || *workers != 1 {
+		t.Errorf("expected level debug, port 9000 from the argument and workers 1 but got %v, %v and %v", *level, *port, *workers)
+	}
+	fs.RUnlock()
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("expected WatchCfg to return context.Canceled but got %v", err)
+	}
+
+	if err := OneCmd("test", ContinueOnError).WatchCfg(context.Background(), time.Millisecond); err == nil {
+		t.Error("expected an error watching without a loaded config")
+	}
+}
+
+func TestLoadCfgs_FailureKeepsLoaded(t *testing.T) {
+	dir := t.TempDir()
+	good := filepath.Join(dir, "good.yaml")
+	bad := filepath.Join(dir, "bad.yaml")
+	err := os.WriteFile(good, []byte("port: 80\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(bad, []byte("port: eighty\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := OneCmd("test", ContinueOnError)
+	port := fs.Int("port", 0, "", fs.Cfg("port"))
+	err = fs.LoadCfg(good)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.LoadCfgs(good, bad)
+	if err == nil {
+		t.Fatal("expected an error loading a value which is not an int")
+	}
+	if *port != 80 || fs.CfgPath() != good || fs.CfgOrigin("port") != good {
+		t.Errorf("expected port 80 from %v to be kept but got %v from %v", good, *port, fs.CfgOrigin("port"))
+	}
+}
@@ -0,0 +1,261 @@
+package flag
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// CompletionCmdName is the name of the hidden sub-command the generated completion scripts
+// run to ask the program for the candidates of the word being completed.
+// the shell passes the words typed so far (without the program name), the last one being the word to complete,
+// and the program prints one candidate per line.
+const CompletionCmdName = "__complete"
+
+// osExit is used to exit the program once the completion candidates are printed
+var osExit = os.Exit
+
+// handlerStopped is panicked by the Parse of a sub command while completing,
+// so the sub command handler stops right after defining its flags
+type handlerStopped struct{}
+
+// EnableCompletion adds the hidden __complete sub command to the root command,
+// which the scripts generated by GenCompletion run to get the candidates.
+// call it before Parse, a program without it treats __complete like any other argument.
+func (f *Command) EnableCompletion() {
+	root := f.root()
+	if root.SubCmds == nil {
+		root.SubCmds = make(map[string]*subCommand)
+	}
+	root.SubCmds[CompletionCmdName] = &subCommand{
+		fn: func(ctx context.Context, fs *Command, args []string) error {
+			fs.parentCmd.runCompletion(args)
+			return nil
+		},
+		fs:     &Command{name: CompletionCmdName, parentCmd: root},
+		hidden: true,
+	}
+}
+
+// runCompletion prints the completion candidates for args and exits the program
+func (f *Command) runCompletion(args []string) {
+	f.completing = true
+	defer func() {
+		f.completing = false
+	}()
+	f.writeCompletions(args)
+	osExit(0)
+}
+
+// writeCompletions prints the candidates for args to the completion output of f,
+// sub commands are walked by running their handler until they call Parse
+func (f *Command) writeCompletions(args []string) {
+	defer func() {
+		if e := recover(); e != nil {
+			if _, ok := e.(handlerStopped); !ok {
+				panic(e)
+			}
+		}
+	}()
+	candidates, sc, scArgs := f.complete(args)
+	if sc != nil {
+		// the sub command defines its flags and calls Parse, which completes and stops the handler
+		sc.fn(f.Context(), sc.fs, scArgs)
+		return
+	}
+	out := f.root().completionOutput()
+	for _, c := range candidates {
+		fmt.Fprintln(out, c)
+	}
+}
+
+// completionOutput is where completion candidates are written, os.Stdout unless SetOutput is called
+func (f *Command) completionOutput() io.Writer {
+	if f.output == nil {
+		return os.Stdout
+	}
+	return f.output
+}
+
+// complete returns the candidates for the last word in args,
+// or the sub command (and its arguments) which should complete it instead.
+func (f *Command) complete(args []string) (candidates []string, sc *subCommand, scArgs []string) {
+	toComplete := ""
+	if len(args) > 0 {
+		toComplete, args = args[len(args)-1], args[:len(args)-1]
+	}
+	seenPositional := false
+	for i := 0; i < len(args); i++ {
+		s := args[i]
+		if s == "--" {
+			// only positional arguments from here, let the shell complete them
+			return nil, nil, nil
+		}
+		if len(s) > 1 && s[0] == '-' {
+			name := strings.TrimLeft(s, "-")
+			if strings.Contains(name, "=") {
+				continue
+			}
+			flag, _ := f.lookupFlag(name)
+			if flag == nil || isBoolValue(flag.Value) {
+				continue
+			}
+			if i == len(args)-1 {
+				// completing the value of the flag
+				return filterPrefix(keys(flag.enums), toComplete), nil, nil
+			}
+			i++
+			continue
+		}
+		if !seenPositional {
+			if sc, ok := f.SubCmds[s]; ok && !sc.hidden {
+				return nil, sc, append(append([]string{}, args[i+1:]...), toComplete)
+			}
+		}
+		seenPositional = true
+	}
+	if strings.HasPrefix(toComplete, "-") {
+		if i := strings.Index(toComplete, "="); i > 0 {
+			// completing the value of --flag=
+			flagPart := toComplete[:i+1]
+			flag, _ := f.lookupFlag(strings.TrimLeft(toComplete[:i], "-"))
+			if flag == nil {
+				return nil, nil, nil
+			}
+			for _, enum := range filterPrefix(keys(flag.enums), toComplete[i+1:]) {
+				candidates = append(candidates, flagPart+enum)
+			}
+			return candidates, nil, nil
+		}
+		// the flags of f and the persistent ones of its parents
+		flags := append(sortFlags(f.formal), f.inheritedFlags()...)
+		names := make([]string, 0, len(flags))
+		for _, flag := range flags {
+			names = append(names, "--"+flag.Name)
+		}
+		if !strings.HasPrefix(toComplete, "--") {
+			// single dash, offer the short aliases too
+			for _, flag := range flags {
+				if len(flag.Name) == 1 {
+					names = append(names, "-"+flag.Name)
+				}
+			}
+		}
+		return filterPrefix(names, toComplete), nil, nil
+	}
+	if !seenPositional {
+		for _, sc := range f.visibleSubCmds() {
+			candidates = append(candidates, sc.fs.name)
+		}
+	}
+	return filterPrefix(candidates, toComplete), nil, nil
+}
+
+// filterPrefix returns the sorted entries of candidates starting with prefix
+func filterPrefix(candidates []string, prefix string) []string {
+	var filtered []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			filtered = append(filtered, c)
+		}
+	}
+	sort.Strings(filtered)
+	return filtered
+}
+
+func isBoolValue(v Value) bool {
+	fv, ok := v.(boolFlag)
+	return ok && fv.IsBoolFlag()
+}
+
+var nonIdentifierChars = regexp.MustCompile(`[^a-zA-Z0-9_]`)
+
+// completionFuncName returns a shell function name unique to this program
+func (f *Command) completionFuncName() string {
+	return "__" + nonIdentifierChars.ReplaceAllString(f.root().name, "_") + "_complete"
+}
+
+// GenCompletion writes the completion script for shell (one of bash, zsh or fish) to w.
+// the script runs the program with the hidden __complete sub command to get the candidates,
+// so sub commands, flags, aliases and enum values are always in sync with the program.
+func (f *Command) GenCompletion(w io.Writer, shell string) error {
+	switch shell {
+	case "bash":
+		return f.GenBashCompletion(w)
+	case "zsh":
+		return f.GenZshCompletion(w)
+	case "fish":
+		return f.GenFishCompletion(w)
+	}
+	return fmt.Errorf("unsupported shell %v for completion, use one of [bash, zsh, fish]", shell)
+}
+
+// GenBashCompletion writes the bash completion script to w,
+// source it from your .bashrc or install it in the bash_completion.d directory.
+func (f *Command) GenBashCompletion(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `# bash completion for %[1]v
+%[2]v() {
+    local IFS=$'\n' i word args=()
+    # bash splits --flag=value into --flag, = and value when = is in COMP_WORDBREAKS, join them back
+    for (( i=1; i <= COMP_CWORD; i++ )); do
+        word="${COMP_WORDS[i]}"
+        if (( ${#args[@]} )) && [[ ( "$word" == "=" && "${args[${#args[@]}-1]}" == -* ) || "${args[${#args[@]}-1]}" == -*= ]]; then
+            args[${#args[@]}-1]+="$word"
+        else
+            args+=("$word")
+        fi
+    done
+    COMPREPLY=( $("${COMP_WORDS[0]}" %[3]v "${args[@]}" 2>/dev/null) )
+    local cur="${args[${#args[@]}-1]}"
+    if [[ "$cur" == -*=* && "$COMP_WORDBREAKS" == *=* ]]; then
+        # bash replaces only what follows the last =
+        COMPREPLY=( "${COMPREPLY[@]#"${cur%%=*}="}" )
+    fi
+}
+complete -o default -F %[2]v %[1]v
+`, f.root().name, f.completionFuncName(), CompletionCmdName)
+	return err
+}
+
+// GenZshCompletion writes the zsh completion script to w,
+// source it from your .zshrc or install it as _<name> in your $fpath.
+func (f *Command) GenZshCompletion(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `#compdef %[1]v
+# zsh completion for %[1]v
+%[2]v() {
+    local -a candidates
+    candidates=("${(@f)$(${words[1]} %[3]v "${(@)words[2,$CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+    if (( ${#candidates} )); then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef %[2]v %[1]v
+`, f.root().name, f.completionFuncName(), CompletionCmdName)
+	return err
+}
+
+// GenFishCompletion writes the fish completion script to w,
+// install it as <name>.fish in ~/.config/fish/completions.
+func (f *Command) GenFishCompletion(w io.Writer) error {
+	_, err := fmt.Fprintf(w, `# fish completion for %[1]v
+function %[2]v
+    set -l args (commandline -opc)
+    set -e args[1]
+    set -l candidates (%[1]v %[3]v $args (commandline -ct) 2>/dev/null)
+    if test (count $candidates) -eq 0
+        __fish_complete_path (commandline -ct)
+    else
+        printf '%%s\n' $candidates
+    end
+end
+complete -c %[1]v -f -a '(%[2]v)'
+`, f.root().name, f.completionFuncName(), CompletionCmdName)
+	return err
+}
@@ -0,0 +1,102 @@
+package flag_test
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func completionCmd(out *bytes.Buffer) *Command {
+	git := OneCmd("git", ContinueOnError).(*Command)
+	git.SetOutput(out)
+	git.EnableCompletion()
+	git.Bool("verbose", false, "", git.Alias("v"))
+	git.String("level", "info", "", git.Persistent(), git.Enum("info", "debug"))
+	git.SubCmd("commit", "", func(commitCmd Cmd, args []string) {
+		commitCmd.String("branch", "", "", commitCmd.Alias("b"))
+		commitCmd.String("mode", "fast", "", commitCmd.Enum("fast", "slow", "safe"))
+		err := commitCmd.Parse(args)
+		if err != nil {
+			panic(err)
+		}
+		panic("handler should not run while completing")
+	})
+	git.SubCmd("remote", "", func(remoteCmd Cmd, args []string) {})
+	return git
+}
+
+func TestCompletion(t *testing.T) {
+	exitCode := -1
+	defer SetOsExit(func(code int) { exitCode = code })()
+	tests := []struct {
+		args []string
+		want []string
+	}{
+		{args: []string{""}, want: []string{"commit", "remote"}},
+		{args: []string{"co"}, want: []string{"commit"}},
+		{args: []string{"--v"}, want: []string{"--v", "--verbose"}},
+		{args: []string{"-"}, want: []string{"--level", "--v", "--verbose", "-v"}},
+		{args: []string{"-v", "commit", "--b"}, want: []string{"--b", "--branch"}},
+		{args: []string{"commit", "--mode", "s"}, want: []string{"safe", "slow"}},
+		{args: []string{"commit", "--mode="}, want: []string{"--mode=fast", "--mode=safe", "--mode=slow"}},
+		{args: []string{"commit", "--branch", ""}, want: nil},
+		{args: []string{"commit", "--l"}, want: []string{"--level"}},
+		{args: []string{"commit", "--level", "d"}, want: []string{"debug"}},
+		{args: []string{"commit", "--level="}, want: []string{"--level=debug", "--level=info"}},
+	}
+	for _, tt := range tests {
+		t.Run(strings.Join(tt.args, " "), func(t *testing.T) {
+			out := &bytes.Buffer{}
+			git := completionCmd(out)
+			err := git.Parse(append([]string{CompletionCmdName}, tt.args...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if exitCode != 0 {
+				t.Fatalf("expected exit code 0 but got %v", exitCode)
+			}
+			got := strings.Fields(out.String())
+			if len(got) == 0 {
+				got = nil
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("expected candidates %v but got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestCompletion_NotEnabled(t *testing.T) {
+	fs := OneCmd("echo", ContinueOnError)
+	err := fs.Parse([]string{CompletionCmdName, "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Args(); len(got) != 2 || got[0] != CompletionCmdName {
+		t.Errorf("expected %v to be a positional argument without EnableCompletion but got %v", CompletionCmdName, got)
+	}
+}
+
+func TestGenCompletion(t *testing.T) {
+	git := completionCmd(&bytes.Buffer{})
+	for _, shell := range []string{"bash", "zsh", "fish"} {
+		b := &bytes.Buffer{}
+		err := git.GenCompletion(b, shell)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(b.String(), "__git_complete") || !strings.Contains(b.String(), CompletionCmdName) {
+			t.Errorf("unexpected %v completion script:\n%v", shell, b.String())
+		}
+	}
+	if err := git.GenCompletion(&bytes.Buffer{}, "powershell"); err == nil {
+		t.Error("expected error for unsupported shell")
+	}
+	usage, _ := git.GetDefaultUsage()
+	if strings.Contains(usage, CompletionCmdName) {
+		t.Errorf("hidden completion sub command should not be listed in the usage:\n%v", usage)
+	}
+}
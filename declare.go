@@ -0,0 +1,90 @@
+package flag
+
+import (
+	"context"
+)
+
+// NewSubCmd declares the sub command name and returns it, define its flags and positional arguments on it right away,
+// so they are known before any handler runs, by the usage of the parent, VisitCmds, completion and WriteCfg.
+// when the sub command is run its arguments are parsed for you and run gets the positional arguments,
+// run can be nil for a sub command which only groups other sub commands, running it without one of them
+// prints its usage and fails.
+func (fs *Command) NewSubCmd(name string, usage string, run Handler) Cmd {
+	fs.addSubCmd(name, usage, func(ctx context.Context, fs *Command, args []string) error {
+		return fs.Parse(args)
+	})
+	sc := fs.SubCmds[name]
+	// its flags are defined, there is no handler to run to define them
+	sc.ran = true
+	sc.declared = true
+	if run == nil {
+		sc.fs.needsSubCmd = true
+		return sc.fs
+	}
+	sc.fs.Run(func(cmd Cmd, args []string) error {
+		return run(cmd.Context(), cmd, args)
+	})
+	return sc.fs
+}
+
+// VisitCmds calls fn for the command and every sub command under it which is not hidden,
+// parents before their sub commands, sub commands sorted by name.
+// the flags of a sub command added with SubCmd are defined only once its handler ran, use NewSubCmd to declare them.
+func (f *Command) VisitCmds(fn func(cmd Cmd)) {
+	fn(f)
+	for _, sc := range f.visibleSubCmds() {
+		sc.fs.VisitCmds(fn)
+	}
+}
+
+// Description returns the usage of the command given to SubCmd, NewSubCmd or MainCmd.
+func (f *Command) Description() string {
+	return f.usg
+}
+
+// Envs returns the envs bound to the flag, in the order they are tried.
+func (f *Flag) Envs() []string {
+	if len(f.envList) > 0 {
+		return append([]string{}, f.envList...)
+	}
+	return sortedKeys(f.envs)
+}
+
+// Cfgs returns the cfg keys bound to the flag, in the order they are tried.
+func (f *Flag) Cfgs() []string {
+	if len(f.cfgList) > 0 {
+		return append([]string{}, f.cfgList...)
+	}
+	return sortedKeys(f.cfgs)
+}
+
+// Enums returns the allowed values of the flag, sorted, nil if any value is allowed.
+func (f *Flag) Enums() []string {
+	if len(f.enums) == 0 {
+		return nil
+	}
+	return sortedKeys(f.enums)
+}
+
+// Aliases returns the other names of the flag, sorted.
+func (f *Flag) Aliases() []string {
+	if len(f.alias) == 0 {
+		return nil
+	}
+	return sortedKeys(f.alias)
+}
+
+// AliasFor returns the name of the flag this flag is an alias for, empty if it is not an alias.
+func (f *Flag) AliasFor() string {
+	return f.aliasFor
+}
+
+// IsRequired reports whether the flag is marked Required.
+func (f *Flag) IsRequired() bool {
+	return f.required
+}
+
+// IsPersistent reports whether the flag is marked Persistent.
+func (f *Flag) IsPersistent() bool {
+	return f.global
+}
@@ -0,0 +1,104 @@
+package flag_test
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestNewSubCmd(t *testing.T) {
+	app := OneCmd("app", ContinueOnError)
+	var serveArgs []string
+	serve := app.NewSubCmd("serve", "starts the server", func(ctx context.Context, cmd Cmd, args []string) error {
+		serveArgs = args
+		return nil
+	})
+	port := serve.Int("port", 80, "port to listen", serve.Env("PORT"), serve.Alias("p"), serve.Required())
+	serve.String("mode", "dev", "", serve.Enum("dev", "prod"), serve.Cfg("serve.mode"))
+	db := app.NewSubCmd("db", "database commands", nil)
+	ran := false
+	db.NewSubCmd("migrate", "", func(ctx context.Context, cmd Cmd, args []string) error {
+		ran = true
+		return nil
+	})
+
+	// the whole tree is known before any handler runs
+	var cmds []string
+	app.VisitCmds(func(cmd Cmd) {
+		var flags []string
+		cmd.VisitAll(func(f *Flag) {
+			flags = append(flags, f.Name)
+		})
+		cmds = append(cmds, cmd.Name()+" "+strings.Join(flags, ","))
+	})
+	want := []string{"app ", "db ", "migrate ", "serve mode,p,port"}
+	if !reflect.DeepEqual(cmds, want) {
+		t.Errorf("expected the commands and flags %v but got %v", want, cmds)
+	}
+	f := serve.Lookup("port")
+	if !reflect.DeepEqual(f.Envs(), []string{"PORT"}) || !reflect.DeepEqual(f.Aliases(), []string{"p"}) || !f.IsRequired() {
+		t.Errorf("expected env PORT, alias p and required but got %v, %v and %v", f.Envs(), f.Aliases(), f.IsRequired())
+	}
+	if f := serve.Lookup("mode"); !reflect.DeepEqual(f.Enums(), []string{"dev", "prod"}) || !reflect.DeepEqual(f.Cfgs(), []string{"serve.mode"}) {
+		t.Errorf("expected enums dev, prod and cfg serve.mode but got %v and %v", f.Enums(), f.Cfgs())
+	}
+	if f := serve.Lookup("p"); f.AliasFor() != "port" {
+		t.Errorf("expected p to be an alias for port but got %q", f.AliasFor())
+	}
+	usage, _ := app.GetDefaultUsageLong()
+	if !strings.Contains(usage, "flags: --mode, --p, --port") {
+		t.Errorf("expected the flags of serve in the usage of app but got\n%v", usage)
+	}
+
+	err := app.Parse([]string{"serve"})
+	if err == nil || !strings.Contains(err.Error(), "--port") {
+		t.Errorf("expected the missing required flag of serve but got %v", err)
+	}
+	err = app.Parse([]string{"serve", "--port", "90", "public"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 90 || !reflect.DeepEqual(serveArgs, []string{"public"}) {
+		t.Errorf("expected port 90 and the positional argument public but got %v and %v", *port, serveArgs)
+	}
+	err = app.Parse([]string{"db", "migrate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ran {
+		t.Error("expected migrate to run")
+	}
+	out := new(bytes.Buffer)
+	app.SetOutput(out)
+	err = app.Parse([]string{"db"})
+	if err == nil || err.Error() != "app db needs a sub command" || ExitCode(err) != 2 {
+		t.Errorf("expected db without a sub command to fail but got %v", err)
+	}
+	if !strings.Contains(out.String(), "migrate") {
+		t.Errorf("expected the usage of db but got\n%v", out.String())
+	}
+}
+
+func TestNewSubCmd_UsageFlags(t *testing.T) {
+	app := OneCmd("app", ContinueOnError)
+	app.SetOutput(io.Discard)
+	app.SubCmd("init", "", func(cmd Cmd, args []string) {
+		cmd.Bool("force", false, "")
+		cmd.Parse(args)
+	})
+	serve := app.NewSubCmd("serve", "", nil)
+	serve.Int("port", 80, "")
+	err := app.Parse([]string{"init"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	usage, _ := app.GetDefaultUsageLong()
+	if !strings.Contains(usage, "flags: --port") || strings.Contains(usage, "--force") {
+		t.Errorf("expected the flags of the declared sub commands only but got\n%v", usage)
+	}
+}
@@ -0,0 +1,61 @@
+package flag
+
+import (
+	"fmt"
+	"os"
+)
+
+// SetEnvOverlay makes LoadEnv keep the loaded envs in an overlay of the command tree instead of calling os.Setenv,
+// the overlay is consulted before the environment of the process when binding envs,
+// so commands running in parallel (like in tests) don't race on the process environment.
+func (f *Command) SetEnvOverlay(enabled bool) {
+	f.root().envScoped = enabled
+}
+
+// SetEnvOverride sets whether LoadEnv overrides the envs already set in the environment of the process
+// (and the ones loaded before), true by default. when false an env already set keeps its value.
+func (f *Command) SetEnvOverride(override bool) {
+	f.root().envNoOverride = !override
+}
+
+// LookupEnv returns the value of the env key as the flags see it,
+// from the overlay of the command tree (see SetEnvOverlay) or the environment of the process.
+func (f *Command) LookupEnv(key string) (string, bool) {
+	root := f.root()
+	if root.envNoOverride {
+		if v, ok := os.LookupEnv(key); ok {
+			return v, true
+		}
+	}
+	if v, ok := root.envOverlay[key]; ok {
+		return v, true
+	}
+	return os.LookupEnv(key)
+}
+
+// setEnvs sets envs loaded from a file to the overlay or the environment of the process, following the override policy
+func (f *Command) setEnvs(envs map[string]string) error {
+	errs := ""
+	for k, v := range envs {
+		if f.envNoOverride {
+			if _, ok := f.LookupEnv(k); ok {
+				continue
+			}
+		}
+		if f.envScoped {
+			if f.envOverlay == nil {
+				f.envOverlay = make(map[string]string)
+			}
+			f.envOverlay[k] = v
+			continue
+		}
+		err := os.Setenv(k, v)
+		if err != nil {
+			errs += err.Error() + "\n"
+		}
+	}
+	if errs != "" {
+		return fmt.Errorf("partially loaded envs beacuse : %v", errs)
+	}
+	return nil
+}
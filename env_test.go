@@ -0,0 +1,88 @@
+package flag_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestEnvOverlay(t *testing.T) {
+	for i := 0; i < 4; i++ {
+		i := i
+		t.Run(fmt.Sprint(i), func(t *testing.T) {
+			t.Parallel()
+			path := filepath.Join(t.TempDir(), ".env")
+			err := os.WriteFile(path, []byte(fmt.Sprintf("TEST_OVERLAY_PORT=%v\nTEST_OVERLAY_URL=http://host:${TEST_OVERLAY_PORT}\n", 8000+i)), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs := OneCmd("test", ContinueOnError)
+			fs.SetEnvOverlay(true)
+			port := fs.Int("port", 0, "", fs.Env("TEST_OVERLAY_PORT"))
+			err = fs.LoadEnv(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			url := fs.String("url", "", "", fs.Env("TEST_OVERLAY_URL"))
+			err = fs.Parse(nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if *port != 8000+i || *url != fmt.Sprintf("http://host:%v", 8000+i) {
+				t.Errorf("expected port %v but got %v and url %v", 8000+i, *port, *url)
+			}
+			if _, ok := os.LookupEnv("TEST_OVERLAY_PORT"); ok {
+				t.Error("expected the process environment to be left alone")
+			}
+		})
+	}
+}
+
+func TestEnvOverride(t *testing.T) {
+	t.Setenv("TEST_OVERRIDE_HOST", "real")
+	dir := t.TempDir()
+	first := filepath.Join(dir, "first.env")
+	second := filepath.Join(dir, "second.env")
+	err := os.WriteFile(first, []byte("TEST_OVERRIDE_HOST=file\nTEST_OVERRIDE_LEVEL=info\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(second, []byte("TEST_OVERRIDE_LEVEL=debug\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, overlay := range []bool{true, false} {
+		t.Run(fmt.Sprint("overlay ", overlay), func(t *testing.T) {
+			// LoadEnv without the overlay sets the env to the process, t.Setenv unsets it again after the test
+			t.Setenv("TEST_OVERRIDE_LEVEL", "")
+			os.Unsetenv("TEST_OVERRIDE_LEVEL")
+			fs := OneCmd("test", ContinueOnError)
+			fs.SetEnvOverlay(overlay)
+			fs.SetEnvOverride(false)
+			host := fs.String("host", "", "", fs.Env("TEST_OVERRIDE_HOST"))
+			level := fs.String("level", "", "", fs.Env("TEST_OVERRIDE_LEVEL"))
+			for _, path := range []string{first, second} {
+				err := fs.LoadEnv(path)
+				if err != nil {
+					t.Fatal(err)
+				}
+			}
+			if *host != "real" || *level != "info" {
+				t.Errorf("expected the envs already set to be kept but got host %v and level %v", *host, *level)
+			}
+		})
+	}
+
+	fs := OneCmd("test", ContinueOnError)
+	fs.SetEnvOverlay(true)
+	err = fs.LoadEnv(first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := fs.LookupEnv("TEST_OVERRIDE_HOST"); v != "file" {
+		t.Errorf("expected the env file to override by default but got %v", v)
+	}
+}
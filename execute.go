@@ -0,0 +1,161 @@
+package flag
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Handler runs a command, ctx is done when Execute receives SIGINT or SIGTERM, cmd is the command
+// to define the flags on and parse args with. the returned error is returned by the Parse of the parent
+// command and finally by Execute.
+type Handler func(ctx context.Context, cmd Cmd, args []string) error
+
+// ExitError is an error carrying the exit code of the program, see ExitCode.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %v", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
+// parseError is an error in the arguments, like an unknown flag, a missing required flag or a bad positional argument
+type parseError struct {
+	err error
+}
+
+func (e *parseError) Error() string {
+	return e.err.Error()
+}
+
+func (e *parseError) Unwrap() error {
+	return e.err
+}
+
+// usageError marks err as an error in the arguments, ErrHelp is left as it is
+func usageError(err error) error {
+	if err == ErrHelp {
+		return err
+	}
+	return &parseError{err: err}
+}
+
+// ExitCode returns the exit code of the program for an error returned by Execute or Parse,
+// 0 for nil and ErrHelp, the Code of an ExitError in the chain of err, 128+signal when stopped by a signal,
+// 2 for an error in the arguments (like an unknown flag) and 1 otherwise.
+func ExitCode(err error) int {
+	if err == nil || errors.Is(err, ErrHelp) {
+		return 0
+	}
+	var exitErr *ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.Code
+	}
+	var parseErr *parseError
+	if errors.As(err, &parseErr) {
+		return 2
+	}
+	return 1
+}
+
+// NewCmd returns a root command which runs fn on Execute.
+func NewCmd(name string, usage string, errorHandling ErrorHandling, fn Handler) Cmd {
+	f := newCommand(name, usage, errorHandling)
+	f.handler = fn
+	return f
+}
+
+// SubCmdContext adds a sub command like SubCmd, with a handler which gets a context and returns an error.
+func (fs *Command) SubCmdContext(name string, usage string, fn Handler) {
+	fs.addSubCmd(name, usage, func(ctx context.Context, fs *Command, args []string) error {
+		return fn(ctx, fs, args)
+	})
+}
+
+// Context returns the context of the running Execute, context.Background() if there is none.
+func (f *Command) Context() context.Context {
+	if ctx := f.root().ctx; ctx != nil {
+		return ctx
+	}
+	return context.Background()
+}
+
+// Execute runs the handler of the command (given to NewCmd) with args, usually os.Args[1:],
+// or only parses args if it has none. ctx is cancelled on SIGINT or SIGTERM, a second signal kills the program.
+// with ExitOnError the error is printed and the program exits with its ExitCode, with PanicOnError it panics,
+// with ContinueOnError it is returned.
+func (f *Command) Execute(ctx context.Context, args []string) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+	stoppedBy := make(chan os.Signal, 1)
+	go func() {
+		select {
+		case sig := <-sigs:
+			// the default behaviour is back for the next signal
+			signal.Stop(sigs)
+			stoppedBy <- sig
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	f.ctx = ctx
+	defer func() {
+		f.ctx = nil
+	}()
+	err := f.execute(ctx, args)
+	select {
+	case sig := <-stoppedBy:
+		if err == nil || errors.Is(err, context.Canceled) {
+			err = fmt.Errorf("stopped by %v", sig)
+		}
+		code := 1
+		if s, ok := sig.(syscall.Signal); ok {
+			code = 128 + int(s)
+		}
+		err = &ExitError{Code: code, Err: err}
+	default:
+	}
+	if err == nil {
+		return nil
+	}
+	switch f.errorHandling {
+	case ExitOnError:
+		if !errors.Is(err, ErrHelp) {
+			fmt.Fprintln(f.Output(), err)
+		}
+		os.Exit(ExitCode(err))
+	case PanicOnError:
+		panic(err)
+	}
+	return err
+}
+
+// execute runs the handler of f with args followed by its post run hooks, or parses args if f has no handler
+func (f *Command) execute(ctx context.Context, args []string) error {
+	if f.handler == nil {
+		return f.Parse(args)
+	}
+	f.handled = true
+	err := f.handler(ctx, f, args)
+	postErr := f.runPostHooks()
+	if err == nil {
+		err = postErr
+	}
+	return err
+}
@@ -0,0 +1,118 @@
+package flag_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+type ctxKey struct{}
+
+func TestExecute(t *testing.T) {
+	failed := &ExitError{Code: 3, Err: errors.New("migration failed")}
+	var gotValue interface{}
+	var closed bool
+	app := NewCmd("app", "", ContinueOnError, func(ctx context.Context, cmd Cmd, args []string) error {
+		cmd.PersistentPostRun(func(cmd Cmd, args []string) error {
+			closed = true
+			return nil
+		})
+		cmd.SubCmdContext("migrate", "", func(ctx context.Context, cmd Cmd, args []string) error {
+			steps := cmd.Int("steps", 1, "")
+			err := cmd.Parse(args)
+			if err != nil {
+				return err
+			}
+			gotValue = cmd.Context().Value(ctxKey{})
+			if *steps > 2 {
+				return fmt.Errorf("applying %v steps: %w", *steps, failed)
+			}
+			return nil
+		})
+		return cmd.Parse(args)
+	})
+	ctx := context.WithValue(context.Background(), ctxKey{}, "v")
+	err := app.Execute(ctx, []string{"migrate", "--steps", "5"})
+	if !errors.Is(err, failed) {
+		t.Fatalf("expected the error of the sub command but got %v", err)
+	}
+	if code := ExitCode(err); code != 3 {
+		t.Errorf("expected exit code 3 but got %v", code)
+	}
+	if gotValue != "v" {
+		t.Errorf("expected the context given to Execute but got value %v", gotValue)
+	}
+	if !closed {
+		t.Error("expected the post run hooks to run after a failing handler")
+	}
+	err = app.Execute(ctx, []string{"migrate", "--steps=2"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = app.Execute(ctx, []string{"migrate", "--unknown"})
+	if code := ExitCode(err); err == nil || code != 2 {
+		t.Errorf("expected a parse error with exit code 2 but got %v with %v", err, code)
+	}
+}
+
+func TestParse_PanicOnError(t *testing.T) {
+	failed := errors.New("migration failed")
+	app := OneCmd("app", PanicOnError)
+	app.SubCmdContext("migrate", "", func(ctx context.Context, cmd Cmd, args []string) error {
+		err := cmd.Parse(args)
+		if err != nil {
+			return err
+		}
+		return failed
+	})
+	defer func() {
+		if e := recover(); e != failed {
+			t.Errorf("expected the error of the handler to panic but got %v", e)
+		}
+	}()
+	app.Parse([]string{"migrate"})
+}
+
+func TestExitCode(t *testing.T) {
+	tests := map[error]int{
+		nil:             0,
+		ErrHelp:         0,
+		errors.New("x"): 1,
+		fmt.Errorf("w: %w", OneCmd("x", ContinueOnError).Parse([]string{"--unknown"})): 2,
+		&ExitError{Code: 4}:          4,
+		fmt.Errorf("w: %w", ErrHelp): 0,
+	}
+	for err, want := range tests {
+		if got := ExitCode(err); got != want {
+			t.Errorf("expected exit code %v for %v but got %v", want, err, got)
+		}
+	}
+}
+
+func TestExecute_Signal(t *testing.T) {
+	app := NewCmd("app", "", ContinueOnError, func(ctx context.Context, cmd Cmd, args []string) error {
+		p, err := os.FindProcess(os.Getpid())
+		if err != nil {
+			return err
+		}
+		err = p.Signal(os.Interrupt)
+		if err != nil {
+			t.Skip("can't send an interrupt on this platform: ", err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(5 * time.Second):
+			return errors.New("context is not cancelled by the interrupt")
+		}
+	})
+	err := app.Execute(context.Background(), nil)
+	if code := ExitCode(err); code != 130 {
+		t.Errorf("expected exit code 130 for an interrupt but got %v from %v", code, err)
+	}
+}
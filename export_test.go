@@ -3,3 +3,12 @@
 // license that can be found in the LICENSE file.
 
 package flag
+
+// SetOsExit replaces the exit function used once the completion candidates are printed
+func SetOsExit(fn func(int)) (restore func()) {
+	old := osExit
+	osExit = fn
+	return func() {
+		osExit = old
+	}
+}
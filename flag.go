@@ -1,22 +1,23 @@
 package flag
 
 import (
+	"context"
 	"encoding"
 	"errors"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
-	"path/filepath"
 	"reflect"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 )
 
-// Deprecated: rather call GetDefaultUsage() based on the error you get while calling Parse(args)
-// ErrHelp is the error returned if the -help or -h flag is invoked
-// but no such flag is defined.
+// ErrHelp is the error returned if the -help or -h flag or the help sub-command is invoked
+// but no such flag or sub-command is defined, the usage is printed to the output of the command before returning it.
 var ErrHelp = errors.New("flag: help requested")
 
 // errParse is returned by Set if a flag's value fails to parse, such as with an invalid integer for Int.
@@ -293,6 +294,18 @@ const (
 	PanicOnError                         // Call panic with a descriptive error.
 )
 
+// ParseMode defines how Command.Parse reads the flags from the arguments.
+type ParseMode int
+
+// These constants cause Command.Parse to read the flags as described.
+const (
+	// -name and --name are the same, -abc is the flag named abc
+	DefaultParsing ParseMode = iota
+	// GNU/POSIX style, long names need --name, single letter flags (or aliases) use a single dash and can be
+	// clustered (-vvx), the value can be attached to a single letter flag (-p8080), -- terminates the flags
+	GNUParsing
+)
+
 // A Flag represents the state of a flag.
 type Flag struct {
 	Name     string // name as it appears on command line
@@ -302,9 +315,17 @@ type Flag struct {
 
 	envs     map[string]bool
 	cfgs     map[string]bool
+	envList  []string // envs in the order they were bound, the first one with a value wins
+	cfgList  []string // cfgs in the order they were bound, the first one with a value wins
 	enums    map[string]bool
 	alias    map[string]bool
-	aliasFor string //this flag is an alias for
+	aliasFor string       //this flag is an alias for
+	required bool         // Parse fails if the flag is not set by any argument, env or cfg
+	skipEnv  bool         // opted out of AutoEnv
+	skipCfg  bool         // opted out of AutoCfg
+	global   bool         // persistent, accepted by the sub commands too
+	source   *ValueSource // where the value came from, shared with the aliases
+	reset    func() error // sets the default value back, captured when the flag is defined, nil to Set DefValue
 }
 
 func isEnumValid(e string, enums []string) bool {
@@ -341,6 +362,7 @@ func qKeys(m map[string]bool) []string {
 	for key := range m {
 		keys = append(keys, fmt.Sprintf("%q", key))
 	}
+	sort.Strings(keys)
 	return keys
 }
 
@@ -489,7 +511,8 @@ func (f *Command) varErr(value Value, name string, usage string, features ...*fl
 	}
 
 	// Remember the default value as a string; it won't change.
-	flag := &Flag{Name: name, Usage: usage, Value: value, DefValue: value.String(), envs: make(map[string]bool), cfgs: make(map[string]bool), enums: make(map[string]bool), alias: make(map[string]bool)}
+	flag := &Flag{Name: name, Usage: usage, Value: value, DefValue: value.String(), envs: make(map[string]bool), cfgs: make(map[string]bool), enums: make(map[string]bool), alias: make(map[string]bool), source: &ValueSource{}}
+	flag.reset = defaultSetter(value)
 	_, alreadythere := f.formal[name]
 	if alreadythere {
 		var msg string
@@ -509,12 +532,21 @@ func (f *Command) varErr(value Value, name string, usage string, features ...*fl
 	for _, feature := range sortedFeatures {
 		feature.add(f, flag)
 	}
+	f.bindAutoEnv(flag)
+	f.bindAutoCfg(flag)
+	err := f.resolve(flag)
+	if err != nil {
+		return nil, err
+	}
 	return flag, nil
 }
 
 type subCommand struct {
-	fn func(fs *Command, args []string)
-	fs *Command
+	fn       func(ctx context.Context, fs *Command, args []string) error
+	fs       *Command
+	hidden   bool // hidden sub commands are not listed in the usage
+	ran      bool // fn ran on fs, its flags are defined
+	declared bool // added by NewSubCmd, its flags are defined before it runs
 }
 
 // A Command represents a set of defined flags. The zero value of a Command
@@ -541,9 +573,36 @@ type Command struct {
 	errorHandling ErrorHandling
 	output        io.Writer // Deprecated: nil means stderr; use Output() accessor
 	cfgPath       string
+	cfgOrigins    map[string]string // config file of every key of cfg by its dot notation
+	cfgPaths      []string          // config files loaded by the last LoadCfgs, watched by WatchCfg
+	cfgStamps     []string          // modification time and size of cfgPaths when they were loaded
+	cfgCallbacks  []func(changes []CfgChange, err error)
+	envOverlay    map[string]string // envs loaded by LoadEnv when envScoped
+	envScoped     bool              // LoadEnv sets the envs to envOverlay instead of the process environment
+	envNoOverride bool              // LoadEnv keeps the envs already set
+	autoEnv       bool              // AutoEnv was called on this command
+	autoEnvPrefix string            // prefix of the envs bound by AutoEnv
+	autoCfg       bool              // AutoCfg was called on this command
+	autoCfgCase   KeyCase           // case of the cfg keys bound by AutoCfg
+	mu            sync.RWMutex      // guards the flag values against WatchCfg, only the one of the root is used
+	ctx           context.Context   // of the running Execute, only the one of the root is used
 	cfg           *map[string]interface{}
 	SubCmds       map[string]*subCommand
 	parentCmd     *Command
+	completing    bool // true while the hidden completion sub command is running
+	parseMode     ParseMode
+	precedence    []SourceKind // order of the sources tried by resolve, nil means the one of the parent or defaultPrecedence
+	interspersed  bool         // flags are allowed after positional arguments
+	positionals   []*Positional
+	cfgFile       *cfgFile // declared by SetCfgFile
+	cfgFileLoaded string   // path loaded by loadCfgFile, only the one of the root is used
+	hooks         hooks    // set by PreRun, Run, PostRun and their persistent variants
+	handled       bool     // runs in a handler, which runs the post run hooks when it returns
+	needsSubCmd   bool     // declared by NewSubCmd without a run function, Parse fails if none of its sub commands is given
+	helpRequested bool     // run for help <sub-command>..., its first argument is help followed by the rest of the path
+	handler       Handler  // run by Execute, set by NewCmd
+	parseErr      error    // returned by the last Parse
+	printSources  bool     // set by the flag of EnablePrintSources
 }
 
 // sortFlags returns the flags as a slice in lexicographical sorted order.
@@ -570,13 +629,37 @@ func (f *Command) ErrorHandling() ErrorHandling {
 	return f.errorHandling
 }
 
-// Deprecated:
 // SetOutput sets the destination for usage and error messages.
 // If output is nil, os.Stderr is used.
 func (f *Command) SetOutput(output io.Writer) {
 	f.output = output
 }
 
+// SetParseMode sets how Parse reads the flags from the arguments, see ParseMode.
+// sub commands added after this call inherit the mode.
+func (f *Command) SetParseMode(mode ParseMode) {
+	f.parseMode = mode
+}
+
+// SetInterspersed allows flags after positional arguments, Parse permutes the arguments
+// so `tool file.txt --verbose` sets verbose and leaves file.txt in Args(), "--" still terminates the flags.
+// sub commands added after this call inherit it.
+func (f *Command) SetInterspersed(interspersed bool) {
+	f.interspersed = interspersed
+}
+
+// Output returns the destination for usage and error messages. sub commands use the output of their parent
+// and os.Stderr is returned if output was not set or was set to nil.
+func (f *Command) Output() io.Writer {
+	if f.output == nil {
+		if f.parentCmd != nil {
+			return f.parentCmd.Output()
+		}
+		return os.Stderr
+	}
+	return f.output
+}
+
 // VisitAll visits the flags in lexicographical order, calling fn for each.
 // It visits all flags, even those not set.
 func (f *Command) VisitAll(fn func(*Flag)) {
@@ -612,6 +695,7 @@ func (f *Command) Set(name, value string) error {
 		f.actual = make(map[string]*Flag)
 	}
 	f.actual[name] = flag
+	flag.setSource(ValueSource{Kind: SourceArg, Key: name})
 	return nil
 }
 
@@ -682,6 +766,18 @@ func valueTypeName(v Value) (name string) {
 		name = "string"
 	case *uintValue, *uint64Value:
 		name = "uint"
+	case *sliceValue[string]:
+		name = "strings"
+	case *sliceValue[int]:
+		name = "ints"
+	case *sliceValue[float64]:
+		name = "floats"
+	case *sliceValue[time.Duration]:
+		name = "durations"
+	case *mapValue[string]:
+		name = "string=string"
+	case *mapValue[int]:
+		name = "string=int"
 	}
 	return
 }
@@ -716,8 +812,9 @@ func (f *Command) getDefaultUsage(short bool) (usage string, err error) {
 	if f.usg != "" {
 		defaultUsage += fmt.Sprintf("%s\n\n", f.usg)
 	}
-	hasFlags := len(f.formal) > 0
-	hasSubCmds := len(f.SubCmds) > 0
+	globalFlags := f.inheritedFlags()
+	hasFlags := len(f.formal) > 0 || len(globalFlags) > 0
+	hasSubCmds := len(f.visibleSubCmds()) > 0
 
 	currentCmd := f
 	commandName := currentCmd.name
@@ -729,55 +826,55 @@ func (f *Command) getDefaultUsage(short bool) (usage string, err error) {
 			break
 		}
 	}
-	if hasFlags {
-		defaultUsage += fmt.Sprintf("usage:\n  %v [<flags>]\n", commandName)
-	}
-	if hasFlags && hasSubCmds {
-		defaultUsage += "  or\n"
+	var usageLines []string
+	if hasFlags || len(f.positionals) > 0 {
+		usageLine := commandName
+		if hasFlags {
+			usageLine += " [<flags>]"
+		}
+		usageLines = append(usageLines, usageLine+f.argsUsage())
 	}
 	if hasSubCmds {
-		defaultUsage += fmt.Sprintf("  %v [<sub-command>]\n", commandName)
+		usageLines = append(usageLines, fmt.Sprintf("%v [<sub-command>]", commandName))
+	}
+	if len(usageLines) > 0 {
+		defaultUsage += fmt.Sprintf("usage:\n  %v\n", strings.Join(usageLines, "\n  or\n  "))
 	}
 	// list of subcommands
-	if len(f.SubCmds) > 0 {
+	if hasSubCmds {
 		defaultUsage += "\n"
 		defaultUsage += "Available sub commands:\n"
-		for _, sc := range f.SubCmds {
+		for _, sc := range f.visibleSubCmds() {
 			defaultUsage += ("  " + sc.fs.name + "  " + sc.fs.usg + "\n")
-		}
-	}
-	if hasFlags {
-		defaultUsage += "\nFlags:\n"
-		for _, flag := range f.formal {
-			usage := flag.Usage
-			if flag.aliasFor == "" {
-				if usage == "" {
-					usage = "usage not available"
-				}
-				bracketUsage := fmt.Sprintf("defaults to \"%v\"", flag.DefValue)
-				if !short {
-					if len(flag.enums) > 0 {
-						bracketUsage += fmt.Sprintf(", possible values [%v]", strings.Join(qKeys(flag.enums), ", "))
-					}
-					if len(flag.alias) > 0 {
-						bracketUsage += fmt.Sprintf(", alias [%v]", strings.Join(qKeys(flag.alias), ", "))
-					}
-					if len(flag.envs) > 0 {
-						bracketUsage += fmt.Sprintf(", binds to env/s [%v]", strings.Join(qKeys(flag.envs), ", "))
-					}
-					if len(flag.cfgs) > 0 {
-						bracketUsage += fmt.Sprintf(", binds to cfg/s [%v]", strings.Join(qKeys(flag.cfgs), ", "))
-					}
+			if !short && sc.declared && len(sc.fs.formal) > 0 {
+				// the flags of the sub command declared by NewSubCmd, those defined by a handler are known only after it ran
+				var names []string
+				for _, flag := range sortFlags(sc.fs.formal) {
+					names = append(names, sc.fs.dashed(flag.Name))
 				}
-				defaultUsage += fmt.Sprintf("  --%v %v  %v, (%v)\n", flag.Name, valueTypeName(flag.Value), usage, bracketUsage)
-			} else {
-				defaultUsage += fmt.Sprintf("  --%v %v  alias for \"--%v\"\n", flag.Name, valueTypeName(flag.Value), flag.aliasFor)
+				defaultUsage += "    flags: " + strings.Join(names, ", ") + "\n"
 			}
-			if hasSubCmds {
-				defaultUsage += fmt.Sprintf("\nUse \"%v [command] --help\" for more information about a command.", commandName)
+		}
+	}
+	if len(f.positionals) > 0 {
+		defaultUsage += "\nArguments:\n"
+		for _, p := range f.positionals {
+			usage := p.Usage
+			if usage == "" {
+				usage = "usage not available"
 			}
+			defaultUsage += fmt.Sprintf("  %v %v  %v\n", p.usage(), valueTypeName(p.Value), usage)
 		}
 	}
+	if len(f.formal) > 0 {
+		defaultUsage += "\nFlags:\n" + f.flagsUsage(sortFlags(f.formal), short)
+	}
+	if len(globalFlags) > 0 {
+		defaultUsage += "\nGlobal Flags:\n" + f.flagsUsage(globalFlags, short)
+	}
+	if hasSubCmds {
+		defaultUsage += fmt.Sprintf("\nUse \"%v [command] --help\" for more information about a command.\n", commandName)
+	}
 	errS := ""
 	if errs := isZeroValueErrs; len(errs) > 0 {
 		for _, err := range errs {
@@ -790,6 +887,52 @@ func (f *Command) getDefaultUsage(short bool) (usage string, err error) {
 	return defaultUsage, err
 }
 
+// flagsUsage returns a line of the usage for every flag in flags
+func (f *Command) flagsUsage(flags []*Flag, short bool) string {
+	defaultUsage := ""
+	for _, flag := range flags {
+		usage := flag.Usage
+		if flag.aliasFor == "" {
+			if usage == "" {
+				usage = "usage not available"
+			}
+			bracketUsage := fmt.Sprintf("defaults to \"%v\"", flag.DefValue)
+			if flag.required {
+				bracketUsage = "required, " + bracketUsage
+			}
+			if !short {
+				if flag.global {
+					bracketUsage += ", persistent"
+				}
+				if len(flag.enums) > 0 {
+					bracketUsage += fmt.Sprintf(", possible values [%v]", strings.Join(qKeys(flag.enums), ", "))
+				}
+				if len(flag.alias) > 0 {
+					bracketUsage += fmt.Sprintf(", alias [%v]", strings.Join(qKeys(flag.alias), ", "))
+				}
+				if len(flag.envs) > 0 {
+					bracketUsage += fmt.Sprintf(", binds to env/s [%v]", strings.Join(qKeys(flag.envs), ", "))
+				}
+				if len(flag.cfgs) > 0 {
+					bracketUsage += fmt.Sprintf(", binds to cfg/s [%v]", strings.Join(qKeys(flag.cfgs), ", "))
+				}
+			}
+			defaultUsage += fmt.Sprintf("  %v %v  %v, (%v)\n", f.dashed(flag.Name), valueTypeName(flag.Value), usage, bracketUsage)
+		} else {
+			defaultUsage += fmt.Sprintf("  %v %v  alias for \"%v\"\n", f.dashed(flag.Name), valueTypeName(flag.Value), f.dashed(flag.aliasFor))
+		}
+	}
+	return defaultUsage
+}
+
+// dashed returns the flag name as it should be passed in the arguments
+func (f *Command) dashed(name string) string {
+	if f.parseMode == GNUParsing && len(name) == 1 {
+		return "-" + name
+	}
+	return "--" + name
+}
+
 // NFlag returns the number of flags that have been set.
 func (f *Command) NFlag() int { return len(f.actual) }
 
@@ -837,6 +980,10 @@ func (f *Command) parseOne() (bool, error) {
 	if len(name) == 0 || name[0] == '-' || name[0] == '=' {
 		return false, fmt.Errorf("bad flag syntax: %s", s)
 	}
+	if f.parseMode == GNUParsing && numMinuses == 1 {
+		f.args = f.args[1:]
+		return f.parseShortFlags(name)
+	}
 
 	// it's a flag. does it have an argument?
 	f.args = f.args[1:]
@@ -850,9 +997,12 @@ func (f *Command) parseOne() (bool, error) {
 			break
 		}
 	}
-	m := f.formal
-	flag, alreadythere := m[name] // BUG
-	if !alreadythere {
+	flag, owner := f.lookupFlag(name)
+	if flag == nil {
+		if name == "help" || name == "h" { // special case for nice help message.
+			f.printUsage(name == "h")
+			return false, ErrHelp
+		}
 		return false, fmt.Errorf("flag provided but not defined: -%s", name)
 	}
 
@@ -876,37 +1026,188 @@ func (f *Command) parseOne() (bool, error) {
 		if !hasValue {
 			return false, fmt.Errorf("flag needs an argument: -%s", name)
 		}
-		if err := flag.Set(value); err != nil {
+		if err := flag.setFromArg(value); err != nil {
 			return false, fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)
 		}
 	}
+	owner.markSetByArg(flag, s[:numMinuses]+name)
+	return true, nil
+}
+
+// markSetByArg records the flag of f as set by the argument key, once its value is set
+func (f *Command) markSetByArg(flag *Flag, key string) {
 	if f.actual == nil {
 		f.actual = make(map[string]*Flag)
 	}
-	f.actual[name] = flag
+	f.actual[strings.TrimLeft(key, "-")] = flag
+	flag.setSource(ValueSource{Kind: SourceArg, Key: key})
+}
+
+// parseShortFlags parses a cluster of single letter flags like -vvx or -p8080 in GNU parsing mode,
+// cluster is the argument without the leading dash.
+func (f *Command) parseShortFlags(cluster string) (bool, error) {
+	if name := strings.SplitN(cluster, "=", 2)[0]; len(name) > 1 && f.formal[name] != nil {
+		return false, fmt.Errorf("flag -%s should be passed as --%s", name, name)
+	}
+	for i := 0; i < len(cluster); i++ {
+		name := cluster[i : i+1]
+		flag, owner := f.lookupFlag(name)
+		if flag == nil {
+			if name == "h" {
+				f.printUsage(true)
+				return false, ErrHelp
+			}
+			return false, fmt.Errorf("flag provided but not defined: -%s in -%s", name, cluster)
+		}
+		rest := cluster[i+1:]
+		if fv, ok := flag.Value.(boolFlag); ok && fv.IsBoolFlag() {
+			if strings.HasPrefix(rest, "=") {
+				if err := fv.Set(rest[1:]); err != nil {
+					return false, fmt.Errorf("invalid boolean value %q for -%s: %v", rest[1:], name, err)
+				}
+				owner.markSetByArg(flag, "-"+name)
+				return true, nil
+			}
+			if err := fv.Set("true"); err != nil {
+				return false, fmt.Errorf("invalid boolean flag %s: %v", name, err)
+			}
+			owner.markSetByArg(flag, "-"+name)
+			continue
+		}
+		// rest of the cluster is the value (-p8080 or -p=8080), otherwise the next argument
+		value := strings.TrimPrefix(rest, "=")
+		if value == "" {
+			if len(f.args) == 0 {
+				return false, fmt.Errorf("flag needs an argument: -%s", name)
+			}
+			value, f.args = f.args[0], f.args[1:]
+		}
+		if err := flag.setFromArg(value); err != nil {
+			return false, fmt.Errorf("invalid value %q for flag -%s: %v", value, name, err)
+		}
+		owner.markSetByArg(flag, "-"+name)
+		return true, nil
+	}
 	return true, nil
 }
 
+// printUsage prints the default usage of the command to its output, -h prints the short one and --help the long one
+func (f *Command) printUsage(short bool) {
+	usage, err := f.getDefaultUsage(short)
+	fmt.Fprint(f.Output(), usage)
+	if err != nil {
+		fmt.Fprintln(f.Output(), err)
+	}
+}
+
 // ParseWithoutArgs parses everything like binding cfg, binding env, binding to other flags etc but arguments passed to the
 // program won't be parsed and considered, when you require flag set to act like config loader (viper'ish)
 // still takes in arguments to parse the sub commands passed and run it
 func (f *Command) ParseWithoutArgs(args []string) error {
+	if f.root().completing {
+		f.writeCompletions(args)
+		panic(handlerStopped{})
+	}
 	// it is possible that user is trying run a sub-command
-	_, err := f.parseSubCommandAndRun(args)
-	return err
+	ran, err := f.parseSubCommandAndRun(args)
+	if err != nil || ran {
+		return err
+	}
+	err = f.loadCfgFile()
+	if err != nil {
+		return err
+	}
+	err = f.resolveAll()
+	if err != nil {
+		return err
+	}
+	return f.checkRequired()
 }
 
-// lets us know whether subcommand found in args and ran
+// lets us know whether subcommand found in args and ran,
+// the first of args is the first positional argument, the flags of f before it are already parsed
 func (f *Command) parseSubCommandAndRun(args []string) (bool, error) {
-	SubCmdFsName, SubCmdFsArgs, ok := GetFirstSubCommandWithArgs(args)
-	if ok {
-		sc, ok := f.SubCmds[SubCmdFsName]
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return false, nil
+	}
+	SubCmdFsName, SubCmdFsArgs := args[0], args[1:]
+	if SubCmdFsName == "help" && (f.helpRequested || f.SubCmds["help"] == nil && len(f.visibleSubCmds()) > 0) {
+		// help <sub-command>... is same as <sub-command>... --help, the command is never run
+		f.helpRequested = false
+		if len(SubCmdFsArgs) == 0 {
+			f.printUsage(false)
+			return false, ErrHelp
+		}
+		sc, ok := f.SubCmds[SubCmdFsArgs[0]]
 		if !ok {
-			return false, fmt.Errorf("you are trying to run subcommand with name %v but it doesn't exist", SubCmdFsName)
+			return false, usageError(fmt.Errorf("%v has no sub command %v to show the help of", f.path(), SubCmdFsArgs[0]))
+		}
+		if len(SubCmdFsArgs) == 1 {
+			return f.parseSubCommandAndRun([]string{SubCmdFsArgs[0], "--help"})
+		}
+		// the sub commands of the sub command are known once it parses, it resolves the rest of the help path
+		sc.fs.helpRequested = true
+		defer func() {
+			sc.fs.helpRequested = false
+		}()
+		return f.parseSubCommandAndRun(append([]string{SubCmdFsArgs[0], "help"}, SubCmdFsArgs[1:]...))
+	}
+	sc, ok := f.SubCmds[SubCmdFsName]
+	if !ok {
+		if len(f.visibleSubCmds()) == 0 || len(f.positionals) > 0 {
+			// not a sub command but a positional argument
+			return false, nil
+		}
+		return false, usageError(fmt.Errorf("you are trying to run subcommand with name %v but it doesn't exist", SubCmdFsName))
+	}
+	// the sub command binds to the config file of its parent, which its flags may have set
+	err := f.loadCfgFile()
+	if err != nil {
+		return false, err
+	}
+	// the flags of the parent are ready for the sub command
+	err = f.resolveAll()
+	if err != nil {
+		return false, err
+	}
+	sc.ran = true
+	err = sc.fn(f.Context(), sc.fs, SubCmdFsArgs)
+	if err == nil && sc.fs.parseErr == ErrHelp {
+		// the handler returned after printing the help of the sub command
+		err = ErrHelp
+	}
+	// the post run hooks run even if the handler failed, to clean up after the pre run ones
+	postErr := sc.fs.runPostHooks()
+	if err == nil {
+		err = postErr
+	}
+	if err != nil && err != sc.fs.parseErr {
+		// not returned by the Parse of the sub command, which handled its error already
+		err = f.handleError(err)
+	}
+	return true, err
+}
+
+// visibleSubCmds returns the sub commands which are not hidden, sorted by name.
+func (f *Command) visibleSubCmds() []*subCommand {
+	var scs []*subCommand
+	for _, sc := range f.SubCmds {
+		if !sc.hidden {
+			scs = append(scs, sc)
 		}
-		sc.fn(sc.fs, SubCmdFsArgs)
 	}
-	return ok, nil
+	sort.Slice(scs, func(i, j int) bool {
+		return scs[i].fs.name < scs[j].fs.name
+	})
+	return scs
+}
+
+// root returns the top most command of the command tree f belongs to.
+func (f *Command) root() *Command {
+	for f.parentCmd != nil {
+		f = f.parentCmd
+	}
+	return f
 }
 
 func GetFirstSubCommandWithArgs(args []string) (string, []string, bool) {
@@ -920,15 +1221,17 @@ func GetFirstSubCommandWithArgs(args []string) (string, []string, bool) {
 	return "", nil, false
 }
 
+// handleError returns err with ContinueOnError, prints it and exits with its ExitCode with ExitOnError
+// and panics with PanicOnError
 func (f *Command) handleError(err error) error {
 	switch f.errorHandling {
 	case ContinueOnError:
 		return err
 	case ExitOnError:
-		if err == ErrHelp {
-			os.Exit(0)
+		if err != ErrHelp {
+			fmt.Fprintln(f.Output(), err)
 		}
-		os.Exit(2)
+		os.Exit(ExitCode(err))
 	case PanicOnError:
 		panic(err)
 	}
@@ -942,30 +1245,132 @@ func (f *Command) handleError(err error) error {
 // are defined and before flags are accessed by the program.
 // The return value will be ErrHelp if -help or -h were set but not defined.
 func (f *Command) Parse(arguments []string) error {
-	ran, err := f.parseSubCommandAndRun(arguments)
-	if err != nil {
-		return f.handleError(err)
-	}
-	// did we find a sub command and ran it?
-	if ran {
-		// then we shouldn't continue running the parent command
-		return nil
+	if f.root().completing {
+		// we are running for the shell completion, the handler shouldn't continue after defining the flags
+		f.writeCompletions(arguments)
+		panic(handlerStopped{})
 	}
-	f.parsed = true
+	err := f.parse(arguments)
+	f.parseErr = err
+	return err
+}
+
+// parse does the work of Parse, the returned error went through the error handling of f
+func (f *Command) parse(arguments []string) error {
 	f.args = arguments
+	var positionals []string
 	for {
+		terminated := len(f.args) > 0 && f.args[0] == "--"
 		seen, err := f.parseOne()
 		if seen {
 			continue
 		}
-		if err == nil {
+		if err != nil {
+			return f.handleError(usageError(err))
+		}
+		if terminated || len(f.args) == 0 {
 			break
 		}
+		if len(positionals) == 0 {
+			// the flags of f before the first positional argument are parsed, it may name a sub command
+			ran, err := f.parseSubCommandAndRun(f.args)
+			// did we find a sub command and ran it?
+			if ran {
+				// then we shouldn't continue running the parent command,
+				// the error of the sub command went through error handling already
+				return err
+			}
+			if err != nil {
+				return f.handleError(err)
+			}
+		}
+		if !f.interspersed {
+			break
+		}
+		// a positional argument, keep looking for flags after it
+		positionals = append(positionals, f.args[0])
+		f.args = f.args[1:]
+	}
+	f.parsed = true
+	f.args = append(positionals, f.args...)
+	err := f.loadCfgFile()
+	if err != nil {
+		return f.handleError(err)
+	}
+	err = f.resolveAll()
+	if err != nil {
+		return f.handleError(err)
+	}
+	if f.root().printSources {
+		fmt.Fprint(f.Output(), f.root().GetValueSources())
+		return f.handleError(ErrHelp)
+	}
+	if f.needsSubCmd && len(f.visibleSubCmds()) > 0 {
+		f.printUsage(true)
+		return f.handleError(usageError(fmt.Errorf("%v needs a sub command", f.path())))
+	}
+	err = f.parsePositionals()
+	if err != nil {
+		return f.handleError(usageError(err))
+	}
+	// the persistent pre run hooks may load the config or env files which set the required flags
+	err = f.runPersistentPreHooks()
+	if err != nil {
+		return f.handleError(err)
+	}
+	err = f.checkRequired()
+	if err != nil {
+		f.runPostHooks()
+		return f.handleError(err)
+	}
+	err = f.runHooks()
+	if err != nil {
 		return f.handleError(err)
 	}
 	return nil
 }
 
+// checkRequired returns a single error listing every required flag, its own and the persistent ones of its parents, which is not set by an argument, env or cfg
+func (f *Command) checkRequired() error {
+	missing := ""
+	// the required persistent flags of the parents are checked by the sub command they dispatched to
+	for _, flag := range append(sortFlags(f.formal), f.inheritedFlags()...) {
+		if !flag.required || flag.aliasFor != "" {
+			continue
+		}
+		if _, owner := f.lookupFlag(flag.Name); owner.isSatisfied(flag) {
+			continue
+		}
+		missing += "\n  --" + flag.Name
+		var sources []string
+		if len(flag.envs) > 0 {
+			sources = append(sources, "env "+strings.Join(qKeys(flag.envs), ", "))
+		}
+		if len(flag.cfgs) > 0 {
+			sources = append(sources, "cfg "+strings.Join(qKeys(flag.cfgs), ", "))
+		}
+		if len(sources) > 0 {
+			missing += " (or set " + strings.Join(sources, " or ") + ")"
+		}
+	}
+	if missing != "" {
+		return usageError(fmt.Errorf("missing required flag/s:%v", missing))
+	}
+	return nil
+}
+
+// isSatisfied reports whether the flag got its value from an argument (including its aliases), an env or a cfg
+func (f *Command) isSatisfied(flag *Flag) bool {
+	if f.setByArg(flag) {
+		return true
+	}
+	if _, _, ok := f.lookupFlagEnv(flag); ok {
+		return true
+	}
+	_, _, ok := f.lookupFlagCfg(flag)
+	return ok
+}
+
 // Parsed reports whether f.Parse has been called.
 func (f *Command) Parsed() bool {
 	return f.parsed
@@ -982,14 +1387,101 @@ type Cmd interface {
 	// effectively making it easier to bind the flags to a env
 	LoadEnv(path string) error
 
+	// keeps the envs loaded by LoadEnv to this command tree instead of the environment of the process
+	SetEnvOverlay(enabled bool)
+
+	// sets whether LoadEnv overrides the envs already set
+	SetEnvOverride(override bool)
+
+	// value of an env as the flags see it
+	LookupEnv(key string) (string, bool)
+
 	// loads a configuration file at path to this command so you can bind configurations
 	LoadCfg(path string) (err error)
 
+	// makes Parse look for a config file named name in the search paths, or load the one passed with --config
+	SetCfgFile(name string, searchPaths ...string)
+
+	// loads the configuration files at paths and deep merges them in order, later files override earlier ones
+	LoadCfgs(paths ...string) (err error)
+
+	// path of the loaded config file the value of key came from
+	CfgOrigin(key string) string
+
+	// loads the configuration read from r in format, an empty format is sniffed from the content
+	LoadCfgFrom(r io.Reader, format Format) error
+
+	// loads the configuration read from r in format, reported by CfgPath and CfgOrigin as coming from name
+	LoadCfgFromNamed(r io.Reader, format Format, name string) error
+
+	// loads the configuration files at paths of fsys and deep merges them in order
+	LoadCfgFS(fsys fs.FS, paths ...string) error
+
+	// writes the effective cfg of this command and its sub commands to the file at path
+	SaveCfg(path string) error
+
+	// writes the effective cfg of this command and its sub commands to w in format
+	WriteCfg(w io.Writer, format Format) error
+
+	// polls the loaded config files and applies their changes until ctx is done
+	WatchCfg(ctx context.Context, interval time.Duration) error
+
+	// registers a callback called after every reload by WatchCfg
+	OnCfgChange(fn func(changes []CfgChange, err error))
+
+	// locks the flag values for reading while WatchCfg is running, not to be held while calling Parse or Load*
+	RLock()
+
+	// undoes a single RLock call
+	RUnlock()
+
+	// path of the loaded config file
+	CfgPath() string
+
 	// introduces a subcommand to this command
 	// you can pass a callback which will recieve a new CMD with name name and args you should parse with the CMD
 	// you recieved after defining the flags
 	SubCmd(name string, usage string, onCmd func(subCmd Cmd, args []string))
 
+	// declares a sub command and returns it to define its flags on, run gets the parsed positional arguments
+	NewSubCmd(name string, usage string, run Handler) Cmd
+
+	// calls fn for the command and every sub command under it
+	VisitCmds(fn func(cmd Cmd))
+
+	// visits all the flags of the command in lexicographical order
+	VisitAll(fn func(*Flag))
+
+	// returns the flag named name, nil if none exists
+	Lookup(name string) *Flag
+
+	// returns the usage of the command given when it was added
+	Description() string
+
+	// adds a sub command like SubCmd, with a handler which gets a context and returns an error
+	SubCmdContext(name string, usage string, fn Handler)
+
+	// runs the handler of the command with args, the context is cancelled on SIGINT or SIGTERM
+	Execute(ctx context.Context, args []string) error
+
+	// returns the context of the running Execute
+	Context() context.Context
+
+	// registers a hook run after the flags are parsed, before the command runs
+	PreRun(hook Hook)
+
+	// sets the hook run after the pre run hooks
+	Run(hook Hook)
+
+	// registers a hook run after the command runs
+	PostRun(hook Hook)
+
+	// registers a hook run before the PreRun of the command and of all of its sub commands
+	PersistentPreRun(hook Hook)
+
+	// registers a hook run after the PostRun of the command and of all of its sub commands
+	PersistentPostRun(hook Hook)
+
 	// add the values possible for the flag you are defining
 	Enum(enums ...string) *flagFeature
 
@@ -999,9 +1491,27 @@ type Cmd interface {
 	// bind the cfg value from the configurtion file you loaded to the flag you are defining
 	Cfg(cfgs ...string) *flagFeature
 
+	// make the flag you are defining accepted by the sub commands of this command too
+	Persistent() *flagFeature
+
+	// mark the flag you are defining as required, Parse fails if no argument, env or cfg sets it
+	Required() *flagFeature
+
 	//bind env to the flag you are defining
 	Env(envs ...string) *flagFeature
 
+	// bind every flag of this command and its sub commands to the env PREFIX_<SUB_COMMAND>_<FLAG_NAME>
+	AutoEnv(prefix string)
+
+	// opt the flag you are defining out of AutoEnv
+	NoAutoEnv() *flagFeature
+
+	// bind every flag of this command and its sub commands to the cfg <sub_command>.<flag_name> in keyCase
+	AutoCfg(keyCase KeyCase)
+
+	// opt the flag you are defining out of AutoCfg
+	NoAutoCfg() *flagFeature
+
 	// BoolVar defines a bool flag with specified name, default value, usage string, and optional flag features.
 	// The argument p points to a bool variable in which to store the value of the flag.
 	BoolVar(p *bool, name string, value bool, usage string, features ...*flagFeature)
@@ -1066,6 +1576,54 @@ type Cmd interface {
 	// The return value is the address of a time.Duration variable that stores the value of the flag.
 	Duration(name string, value time.Duration, usage string, features ...*flagFeature) *time.Duration
 
+	// StringSliceVar defines a []string flag with specified name, default value, usage string, and optional flag features.
+	// The argument p points to a []string variable in which to store the value of the flag.
+	StringSliceVar(p *[]string, name string, value []string, usage string, features ...*flagFeature)
+
+	// StringSlice defines a []string flag with specified name, default value, usage string, and optional flag features.
+	// The return value is the address of a []string variable that stores the value of the flag.
+	StringSlice(name string, value []string, usage string, features ...*flagFeature) *[]string
+
+	// IntSliceVar defines a []int flag with specified name, default value, usage string, and optional flag features.
+	// The argument p points to a []int variable in which to store the value of the flag.
+	IntSliceVar(p *[]int, name string, value []int, usage string, features ...*flagFeature)
+
+	// IntSlice defines a []int flag with specified name, default value, usage string, and optional flag features.
+	// The return value is the address of a []int variable that stores the value of the flag.
+	IntSlice(name string, value []int, usage string, features ...*flagFeature) *[]int
+
+	// Float64SliceVar defines a []float64 flag with specified name, default value, usage string, and optional flag features.
+	// The argument p points to a []float64 variable in which to store the value of the flag.
+	Float64SliceVar(p *[]float64, name string, value []float64, usage string, features ...*flagFeature)
+
+	// Float64Slice defines a []float64 flag with specified name, default value, usage string, and optional flag features.
+	// The return value is the address of a []float64 variable that stores the value of the flag.
+	Float64Slice(name string, value []float64, usage string, features ...*flagFeature) *[]float64
+
+	// DurationSliceVar defines a []time.Duration flag with specified name, default value, usage string, and optional flag features.
+	// The argument p points to a []time.Duration variable in which to store the value of the flag.
+	DurationSliceVar(p *[]time.Duration, name string, value []time.Duration, usage string, features ...*flagFeature)
+
+	// DurationSlice defines a []time.Duration flag with specified name, default value, usage string, and optional flag features.
+	// The return value is the address of a []time.Duration variable that stores the value of the flag.
+	DurationSlice(name string, value []time.Duration, usage string, features ...*flagFeature) *[]time.Duration
+
+	// StringToStringVar defines a map[string]string flag with specified name, default value, usage string, and optional flag features.
+	// The argument p points to a map[string]string variable in which to store the value of the flag.
+	StringToStringVar(p *map[string]string, name string, value map[string]string, usage string, features ...*flagFeature)
+
+	// StringToString defines a map[string]string flag with specified name, default value, usage string, and optional flag features.
+	// The return value is the address of a map[string]string variable that stores the value of the flag.
+	StringToString(name string, value map[string]string, usage string, features ...*flagFeature) *map[string]string
+
+	// StringToIntVar defines a map[string]int flag with specified name, default value, usage string, and optional flag features.
+	// The argument p points to a map[string]int variable in which to store the value of the flag.
+	StringToIntVar(p *map[string]int, name string, value map[string]int, usage string, features ...*flagFeature)
+
+	// StringToInt defines a map[string]int flag with specified name, default value, usage string, and optional flag features.
+	// The return value is the address of a map[string]int variable that stores the value of the flag.
+	StringToInt(name string, value map[string]int, usage string, features ...*flagFeature) *map[string]int
+
 	// TextVar defines a flag with specified name, default value, usage string, and optional flag features.
 	// The argument p is an encoding.TextUnmarshaler that is used to unmarshal the flag value.
 	TextVar(p encoding.TextUnmarshaler, name string, value encoding.TextMarshaler, usage string, features ...*flagFeature)
@@ -1074,9 +1632,62 @@ type Cmd interface {
 	// The argument value is a Value interface that provides the flag's value and default value.
 	Var(value Value, name string, usage string, features ...*flagFeature)
 
+	// ArgVar declares a positional argument with specified name, usage string, and optional argument features.
+	// The argument value is a Value interface that provides the argument's value.
+	ArgVar(value Value, name string, usage string, features ...*argFeature)
+
+	// StringArg declares a string positional argument with specified name, usage string, and optional argument features.
+	// The return value is the address of a string variable that stores the value of the argument.
+	StringArg(name string, usage string, features ...*argFeature) *string
+
+	// IntArg declares an int positional argument with specified name, usage string, and optional argument features.
+	// The return value is the address of an int variable that stores the value of the argument.
+	IntArg(name string, usage string, features ...*argFeature) *int
+
+	// marks the positional argument you are defining as optional
+	OptionalArg() *argFeature
+
+	// makes the positional argument you are defining take all the remaining arguments
+	VariadicArg() *argFeature
+
+	// Arg returns the i'th argument left after the flags.
+	Arg(i int) string
+
+	// NArg is the number of arguments left after the flags.
+	NArg() int
+
+	// Args returns the arguments left after the flags.
+	Args() []string
+
+	// ValueSource returns where the value of the named flag came from.
+	ValueSource(name string) (ValueSource, error)
+
+	// GetValueSources returns a well formatted list of the flags, their values and where the values came from.
+	GetValueSources() string
+
+	// SetPrecedence sets the order in which the arguments, envs and cfgs are tried for the value of a flag.
+	SetPrecedence(kinds ...SourceKind)
+
+	// BindStruct defines a flag for every field of the struct ptr points to, using the field tags
+	// flag, usage, env, cfg, alias and enum.
+	BindStruct(ptr any) error
+
 	// Name returns the name of the FlagSet.
 	Name() string
 
+	// SetOutput sets the destination for usage and error messages.
+	// If output is nil, os.Stderr is used.
+	SetOutput(output io.Writer)
+
+	// Output returns the destination for usage and error messages.
+	Output() io.Writer
+
+	// SetParseMode sets how Parse reads the flags from the arguments, see ParseMode.
+	SetParseMode(mode ParseMode)
+
+	// SetInterspersed allows flags after positional arguments.
+	SetInterspersed(interspersed bool)
+
 	// Set sets the value of the named flag.
 	// It returns an error if the flag does not exist or the value is invalid.
 	Set(name, value string) error
@@ -1097,17 +1708,49 @@ type Cmd interface {
 
 	// Parsed returns whether the command-line arguments have been parsed.
 	Parsed() bool
+
+	// defines the --print-config-sources flag, Parse prints the value sources of the command tree when it is passed
+	EnablePrintSources()
+
+	// EnableCompletion adds the hidden __complete sub command the completion scripts run.
+	EnableCompletion()
+
+	// GenCompletion writes the completion script for shell (one of bash, zsh or fish) to w.
+	GenCompletion(w io.Writer, shell string) error
+
+	// GenBashCompletion writes the bash completion script to w.
+	GenBashCompletion(w io.Writer) error
+
+	// GenZshCompletion writes the zsh completion script to w.
+	GenZshCompletion(w io.Writer) error
+
+	// GenFishCompletion writes the fish completion script to w.
+	GenFishCompletion(w io.Writer) error
 }
 
 // only the thing you'll ever need
 // calls fn with os.args
 // see here https://github.com/ondbyte/turbo_flag#alternative
 func MainCmd(name string, usage string, errorHandling ErrorHandling, onCmd func(cmd Cmd, args []string)) {
-	cfg:=make(map[string]interface{})
+	f := newCommand(name, usage, errorHandling)
+	f.SubCmds = make(map[string]*subCommand)
+	f.handled = true
+	onCmd(f, os.Args[1:])
+	err := f.runPostHooks()
+	if err != nil {
+		if f.errorHandling != ExitOnError {
+			fmt.Fprintln(f.Output(), err)
+		}
+		f.handleError(err)
+	}
+}
+
+// newCommand returns a ready to use root command
+func newCommand(name string, usage string, errorHandling ErrorHandling) *Command {
+	cfg := make(map[string]interface{})
 	f := &Command{
 		name:          name,
 		errorHandling: errorHandling,
-		SubCmds:       make(map[string]*subCommand),
 		ptrs:          make(map[string]*Flag),
 		cfg:           &cfg,
 		usg:           usage,
@@ -1115,7 +1758,7 @@ func MainCmd(name string, usage string, errorHandling ErrorHandling, onCmd func(
 	f.Usage = func() {
 		panic("Deprecated")
 	}
-	onCmd(f, os.Args[1:])
+	return f
 }
 
 // Init sets the name and error handling property for a flag set.
@@ -1128,6 +1771,7 @@ func (f *Command) Init(name string, errorHandling ErrorHandling) {
 
 // loads all environment variables from a env file using os.SetEnv
 // effectively making it easier to bind the flags to a env
+// use SetEnvOverlay to keep them to this command tree instead and SetEnvOverride to keep the envs already set
 func (fs *Command) LoadEnv(path string) error {
 	if fs.parentCmd != nil {
 		return fs.parentCmd.LoadEnv(path)
@@ -1137,91 +1781,142 @@ func (fs *Command) LoadEnv(path string) error {
 	if err != nil {
 		return fmt.Errorf("failed to read env file at %v : %v", path, err)
 	}
-	envs, err := EnvToMap(string(b))
+	envs, err := parseEnv(string(b), fs.LookupEnv)
 	if err != nil {
 		return fmt.Errorf("failed to parse env file content at %v : %v", path, err)
 	}
-	errs := ""
-	for k, v := range envs {
-		err := os.Setenv(k, v)
-		if err != nil {
-			errs += err.Error() + "\n"
-		}
-	}
-	if errs != "" {
-		return fmt.Errorf("partially loaded envs beacuse : %v", err)
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	err = fs.setEnvs(envs)
+	if err != nil {
+		return err
 	}
-	return nil
+	return bindCfgRecursiveAfterLoadCfg(fs)
 }
 
 // loads a cfg to this flagset
 // any sub command defined will also derive from this
 func (fs *Command) LoadCfg(path string) (err error) {
-	if fs.parentCmd != nil {
-		return fs.parentCmd.LoadCfg(path)
-	}
 	if path == "" {
 		return fmt.Errorf("path is empty while loading config")
 	}
+	return fs.LoadCfgs(path)
+}
 
-	fs.cfgPath = path
-	b, err := os.ReadFile(path)
-	if err != nil {
-		return fmt.Errorf("failed to read config file at %v : %v", path, err)
-	}
-	fileContent := string(b)
-	mapContent := make(map[string]interface{})
-	ext := strings.ToUpper(filepath.Ext(path))
-	switch ext {
-	case "":
-		return fmt.Errorf("config file has no extension, add a supported extension [YAML,YML,JSON,PROPERTIES]")
-	case ".JSON":
-		mapContent, err = JSONToMap(fileContent)
-		break
-	case ".YML", ".YAML":
-		mapContent, err = YAMLToMap(fileContent)
-		break
-	case ".TOML":
-		mapContent, err = TOMLToMap(fileContent)
-		break
-	default:
-		return fmt.Errorf("unsupported extension %v", ext)
+// LoadCfgs loads the config files at paths in order and deep merges them into the cfg of this command tree,
+// nested tables are merged and any other value of a later file overrides the one of an earlier file.
+// it replaces any cfg loaded before, use CfgOrigin to know which file a key came from.
+func (fs *Command) LoadCfgs(paths ...string) (err error) {
+	if fs.parentCmd != nil {
+		return fs.parentCmd.LoadCfgs(paths...)
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no path passed while loading config")
+	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	return fs.loadCfgs(paths)
+}
+
+// loadCfgs is LoadCfgs of the root without locking
+func (fs *Command) loadCfgs(paths []string) (err error) {
+	stamps := statCfgs(paths)
+	contents := make([]map[string]interface{}, len(paths))
+	for i, path := range paths {
+		if path == "" {
+			return fmt.Errorf("path is empty while loading config")
+		}
+		contents[i], err = readCfgFile(path)
+		if err != nil {
+			return err
+		}
+	}
+	return fs.setCfg(paths, contents, paths, stamps)
+}
+
+// setCfg deep merges contents in order into the cfg of the root fs, names are the files they were read from,
+// and resolves the flags of the command tree again, watched and stamps are the files for WatchCfg.
+// nothing is changed if the merge fails, the cfg loaded before is restored if the flags fail to resolve.
+func (fs *Command) setCfg(names []string, contents []map[string]interface{}, watched, stamps []string) error {
+	merged := make(map[string]interface{})
+	origins := make(map[string]string)
+	for i, content := range contents {
+		err := mergeCfg(merged, content, "", names[i], origins)
+		if err != nil {
+			return fmt.Errorf("unable to merge config file %v : %v", names[i], err)
+		}
 	}
+	oldPath, oldOrigins, oldCfg, oldPaths, oldStamps := fs.cfgPath, fs.cfgOrigins, *fs.cfg, fs.cfgPaths, fs.cfgStamps
+	fs.cfgPath, fs.cfgOrigins, *fs.cfg, fs.cfgPaths, fs.cfgStamps = names[len(names)-1], origins, merged, watched, stamps
+	err := bindCfgRecursiveAfterLoadCfg(fs)
 	if err != nil {
-		return fmt.Errorf("unable to read config file : %v", err)
+		fs.cfgPath, fs.cfgOrigins, *fs.cfg, fs.cfgPaths, fs.cfgStamps = oldPath, oldOrigins, oldCfg, oldPaths, oldStamps
+		bindCfgRecursiveAfterLoadCfg(fs)
+		return err
 	}
-	*fs.cfg = mapContent
-	bindCfgRecursiveAfterLoadCfg(fs)
 	return nil
 }
 
-func bindCfgRecursiveAfterLoadCfg(fs *Command) {
-	for _, sc := range fs.SubCmds {
-		bindCfgRecursiveAfterLoadCfg(sc.fs)
+// CfgOrigin returns the path of the loaded config file the value of key (in dot notation) came from,
+// empty if no loaded config file has the key.
+func (fs *Command) CfgOrigin(key string) string {
+	return fs.root().cfgOrigins[key]
+}
+
+// readCfgFile reads the config file at path in the format of its extension
+func readCfgFile(path string) (map[string]interface{}, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file at %v : %v", path, err)
 	}
-	for _, flag := range fs.formal {
-		fs.bindCfg(flag, keys(flag.cfgs)...)
+	return decodeCfgFile(path, b)
+}
+
+// bindCfgRecursiveAfterLoadCfg resolves every flag of fs and its sub commands again
+func bindCfgRecursiveAfterLoadCfg(fs *Command) error {
+	for _, sc := range fs.SubCmds {
+		err := bindCfgRecursiveAfterLoadCfg(sc.fs)
+		if err != nil {
+			return err
+		}
 	}
+	return fs.resolveAll()
 }
 
 // adds a new sub flagset to the parent flagset, loads the config file if it exists in the parent
 // the sub command fn recieves the new FlagSet and the arguments thats for the sub command
 // you can add new flags to this sub flagset and call fs.Parse with the arguments you recieved in this function
 func (fs *Command) SubCmd(name string, usage string, fn func(cmd Cmd, args []string)) {
-	subFs := &Command{name: name, errorHandling: fs.errorHandling}
+	fs.addSubCmd(name, usage, func(ctx context.Context, fs *Command, args []string) error {
+		var c Cmd
+		c = fs
+		fn(c, args)
+		return nil
+	})
+}
+
+// addSubCmd adds the sub command name to fs, run by fn
+func (fs *Command) addSubCmd(name string, usage string, fn func(ctx context.Context, fs *Command, args []string) error) {
+	subFs := fs.newSubCmd(name, usage)
+	if fs.SubCmds == nil {
+		fs.SubCmds = make(map[string]*subCommand)
+	}
+	fs.SubCmds[name] = &subCommand{
+		fn: fn,
+		fs: subFs,
+	}
+}
+
+// newSubCmd returns a new sub command of fs, sharing its cfg and parsing settings
+func (fs *Command) newSubCmd(name string, usage string) *Command {
+	subFs := &Command{name: name, errorHandling: fs.errorHandling, parseMode: fs.parseMode, interspersed: fs.interspersed, SubCmds: make(map[string]*subCommand)}
 	subFs.SetUsage(usage)
 	//subFs.LoadCfg(fs.cfgPath)
 	subFs.cfgPath = fs.cfgPath
 	subFs.cfg = fs.cfg
 	subFs.parentCmd = fs
-	fs.SubCmds[name] = &subCommand{
-		fn: func(fs *Command, args []string) {
-			var c Cmd
-			c = fs
-			fn(c, args)
-		},
-		fs: subFs,
-	}
+	subFs.handled = true
+	return subFs
 }
 
 type flagFeature struct {
@@ -1279,6 +1974,7 @@ func (fs *Command) alias(to *Flag, names ...string) {
 		f.cfgs = to.cfgs
 		f.enums = to.enums
 		f.aliasFor = to.Name
+		f.source = to.source
 		for k, v := range to.alias {
 			f.alias[k] = v
 		}
@@ -1287,6 +1983,17 @@ func (fs *Command) alias(to *Flag, names ...string) {
 	}
 }
 
+// marks the flag you are defining as required, Parse reports every required flag
+// which is not set by an argument, any of its envs or any of its cfgs in a single error.
+func (fs *Command) Required() *flagFeature {
+	return &flagFeature{
+		index: 12,
+		add: func(fs *Command, f *Flag) {
+			f.required = true
+		},
+	}
+}
+
 // binds configurations value from config file to the to flag,
 // use dot notation of the config key to bind.
 // https://github.com/ondbyte/turbo_flag#loading-configurations
@@ -1299,26 +2006,62 @@ func (fs *Command) Cfg(cfgs ...string) *flagFeature {
 	}
 }
 
+// bindCfg binds the cfgs to the flag, the value is set by resolve based on the precedence
 func (fs *Command) bindCfg(to *Flag, cfgs ...string) {
-	for _, notation := range cfgs {
-		val, err := getValueByDotNotation(*fs.cfg, notation)
-		if err == nil && val != "" {
-			err := to.Set(val)
-			if err != nil {
-				panic(fmt.Errorf("unable to set notation %v value %v to flag %v", notation, val, to.Name))
+	for _, cfg := range cfgs {
+		if !to.cfgs[cfg] {
+			to.cfgs[cfg] = true
+			to.cfgList = append(to.cfgList, cfg)
+		}
+	}
+}
+
+// cfgValue returns the value of the flag as it should be written to a cfg,
+// a SliceValue as a list, a MapValue as a table, bools, numbers and strings as they are and any other as its string
+func cfgValue(v Value) interface{} {
+	var got interface{}
+	if g, ok := v.(Getter); ok {
+		got = g.Get()
+	}
+	switch v := v.(type) {
+	case SliceValue:
+		list := []interface{}{}
+		elems := reflect.ValueOf(got)
+		for i, s := range v.GetSlice() {
+			if elems.Kind() == reflect.Slice && elems.Len() == len(v.GetSlice()) && isPlainCfgValue(elems.Index(i).Interface()) {
+				list = append(list, elems.Index(i).Interface())
+				continue
 			}
-		} else {
-			cs, err := setValueByDotNotation(*fs.cfg, notation, to.Value.String())
-			if err == nil {
-				for k, v := range cs {
-					(*fs.cfg)[k] = v
+			list = append(list, s)
+		}
+		return list
+	case MapValue:
+		table := map[string]interface{}{}
+		elems := reflect.ValueOf(got)
+		for k, s := range v.GetMap() {
+			if elems.Kind() == reflect.Map {
+				if e := elems.MapIndex(reflect.ValueOf(k)); e.IsValid() && isPlainCfgValue(e.Interface()) {
+					table[k] = e.Interface()
+					continue
 				}
 			}
+			table[k] = s
 		}
+		return table
 	}
-	for _, cfg := range cfgs {
-		to.cfgs[cfg] = true
+	if isPlainCfgValue(got) {
+		return got
+	}
+	return v.String()
+}
+
+// isPlainCfgValue reports whether v can be written to a cfg as it is
+func isPlainCfgValue(v interface{}) bool {
+	switch v.(type) {
+	case bool, int, int64, uint, uint64, float64, string:
+		return true
 	}
+	return false
 }
 
 // binds env/s to the to flag you are defining
@@ -1332,15 +2075,12 @@ func (fs *Command) Env(envs ...string) *flagFeature {
 	}
 }
 
+// bindEnv binds the envs to the flag, the value is set by resolve based on the precedence
 func (fs *Command) bindEnv(to *Flag, envs ...string) {
 	for _, env := range envs {
-		to.envs[env] = true
-		val := os.Getenv(env)
-		if val != "" {
-			err := to.Set(val)
-			if err != nil {
-				panic(fmt.Errorf("error while setting value from environment, flag name %v,env %v,value %v : %v", to.Name, env, val, err))
-			}
+		if !to.envs[env] {
+			to.envs[env] = true
+			to.envList = append(to.envList, env)
 		}
 	}
 }
@@ -1376,9 +2116,5 @@ func (fs *Command) GetFlagForPtr(ptr interface{}) (*Flag, error) {
 
 // use this for just a single command
 func OneCmd(name string, errorHandling ErrorHandling) Cmd {
-	fs := &Command{name: name, errorHandling: errorHandling}
-	fs.Usage = func() {
-		panic("Deprecated")
-	}
-	return fs
+	return newCommand(name, "", errorHandling)
 }
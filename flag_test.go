@@ -2,6 +2,7 @@ package flag_test
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -100,8 +101,56 @@ func TestFlagSet_BindEnv(t *testing.T) {
 	}
 }
 
+func TestFlagSet_LoadCfgs(t *testing.T) {
+	dir := t.TempDir()
+	system := filepath.Join(dir, "system.yaml")
+	user := filepath.Join(dir, "user.json")
+	err := os.WriteFile(system, []byte("server:\n  host: example.com\n  port: 80\n  tls:\n    cert: a.pem\nlevel: info\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(user, []byte(`{"server": {"port": 8080, "tls": false}}`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := OneCmd("test", ContinueOnError)
+	host := fs.String("host", "", "", fs.Cfg("server.host"))
+	port := fs.Int("port", 0, "", fs.Cfg("server.port"))
+	level := fs.String("level", "", "", fs.Cfg("level"))
+	err = fs.LoadCfgs(system, user)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *host != "example.com" || *port != 8080 || *level != "info" {
+		t.Errorf("expected the merged host example.com, port 8080 and level info but got %v, %v and %v", *host, *port, *level)
+	}
+	origins := map[string]string{
+		"server.host":     system,
+		"server.port":     user,
+		"server.tls":      user,
+		"server.tls.cert": "",
+		"level":           system,
+		"nope":            "",
+	}
+	for key, want := range origins {
+		if got := fs.CfgOrigin(key); got != want {
+			t.Errorf("expected %v to come from %q but got %q", key, want, got)
+		}
+	}
+	src, err := fs.ValueSource("host")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if src.File != system {
+		t.Errorf("expected the source file of host to be %v but got %v", system, src.File)
+	}
+	if err := fs.LoadCfgs(system, filepath.Join(dir, "missing.yaml")); err == nil {
+		t.Error("expected an error for a missing config file")
+	}
+}
+
 func TestFlagSet_BindCfg(t *testing.T) {
-	for _, ext := range []string{"json", "yaml", "yml", "toml"} {
+	for _, ext := range []string{"json", "yaml", "yml", "toml", "properties", "ini", "hcl"} {
 		defer func() {
 			err := recover()
 			if err != nil {
@@ -192,6 +241,125 @@ func TestFlagSet_Enums(t *testing.T) {
 	}
 }
 
+func TestFlagSet_Required(t *testing.T) {
+	fs := OneCmd("test", ContinueOnError)
+	fs.String("branch", "", "", fs.Required(), fs.Alias("b"), fs.Env("TEST_REQUIRED_BRANCH"), fs.Cfg("branch.name"))
+	fs.Int("depth", 0, "", fs.Required())
+	fs.Bool("verbose", false, "")
+
+	err := fs.Parse([]string{"--verbose"})
+	if err == nil {
+		t.Fatal("expected error for missing required flags")
+	}
+	want := "missing required flag/s:\n  --branch (or set env \"TEST_REQUIRED_BRANCH\" or cfg \"branch.name\")\n  --depth"
+	if err.Error() != want {
+		t.Fatalf("expected error %q but got %q", want, err)
+	}
+	err = fs.Parse([]string{"-b", "main", "--depth", "1"})
+	if err != nil {
+		t.Fatalf("expected no error but got %v", err)
+	}
+
+	fs = OneCmd("test", ContinueOnError)
+	t.Setenv("TEST_REQUIRED_BRANCH", "main")
+	fs.String("branch", "", "", fs.Required(), fs.Env("TEST_REQUIRED_BRANCH"))
+	err = fs.Parse(nil)
+	if err != nil {
+		t.Fatalf("expected required flag to be satisfied by env but got %v", err)
+	}
+	usage, _ := fs.GetDefaultUsage()
+	if !strings.Contains(usage, "(required, defaults to") {
+		t.Errorf("expected usage to mark the required flag:\n%v", usage)
+	}
+}
+
+func TestGNUParsing(t *testing.T) {
+	fs := OneCmd("test", ContinueOnError)
+	fs.SetParseMode(GNUParsing)
+	var verbosity boolFlagVar
+	fs.Var(&verbosity, "verbose", "", fs.Alias("v"))
+	extract := fs.Bool("extract", false, "", fs.Alias("x"))
+	port := fs.Int("port", 0, "", fs.Alias("p"))
+	name := fs.String("name", "", "", fs.Alias("n"))
+
+	err := fs.Parse([]string{"-vvx", "-p8080", "--name", "turbo", "--", "-v"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if verbosity.count != 2 || !*extract || *port != 8080 || *name != "turbo" {
+		t.Errorf("unexpected values verbose=%v extract=%v port=%v name=%v", verbosity.count, *extract, *port, *name)
+	}
+	if args := fs.(*Command).Args(); len(args) != 1 || args[0] != "-v" {
+		t.Errorf("expected -- to terminate the flags, got args %v", args)
+	}
+	err = fs.Parse([]string{"-xp", "9090", "-n=gnu"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 9090 || *name != "gnu" {
+		t.Errorf("unexpected values port=%v name=%v", *port, *name)
+	}
+	err = fs.Parse([]string{"-port", "1"})
+	if err == nil || err.Error() != "flag -port should be passed as --port" {
+		t.Errorf("expected long flag with single dash to fail, got %v", err)
+	}
+	err = fs.Parse([]string{"-xz"})
+	if err == nil {
+		t.Error("expected unknown short flag in a cluster to fail")
+	}
+	err = fs.Parse([]string{"-p"})
+	if err == nil {
+		t.Error("expected short flag without a value to fail")
+	}
+}
+
+func TestGNUParsing_RejectedValue(t *testing.T) {
+	fs := OneCmd("test", ContinueOnError)
+	fs.SetParseMode(GNUParsing)
+	fs.String("mode", "dev", "", fs.Alias("m"), fs.Enum("dev", "prod"), fs.Required())
+	err := fs.Parse([]string{"-mstaging"})
+	if err == nil {
+		t.Fatal("expected a value not in the enums to fail")
+	}
+	if n := fs.(*Command).NFlag(); n != 0 {
+		t.Errorf("expected a rejected value not to count as set but %v flags are set", n)
+	}
+	if kind := fs.Lookup("mode").Source().Kind; kind == SourceArg {
+		t.Error("expected a rejected value not to be recorded as set by an argument")
+	}
+	err = fs.Parse(nil)
+	if err == nil || !strings.Contains(err.Error(), "--mode") {
+		t.Errorf("expected the required flag to be missing after a rejected value but got %v", err)
+	}
+}
+
+func TestInterspersed(t *testing.T) {
+	fs := OneCmd("test", ContinueOnError)
+	verbose := fs.Bool("verbose", false, "")
+	name := fs.String("name", "", "")
+
+	err := fs.Parse([]string{"file.txt", "--verbose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *verbose {
+		t.Error("flags after a positional argument should not be parsed by default")
+	}
+
+	fs.SetInterspersed(true)
+	err = fs.Parse([]string{"a.txt", "--verbose", "b.txt", "--name", "x", "c.txt", "--", "--name", "y"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !*verbose || *name != "x" {
+		t.Errorf("unexpected values verbose=%v name=%v", *verbose, *name)
+	}
+	want := []string{"a.txt", "b.txt", "c.txt", "--name", "y"}
+	if args := fs.(*Command).Args(); !reflect.DeepEqual(args, want) {
+		t.Errorf("expected args %v but got %v", want, args)
+	}
+}
+
 func boolString(s string) string {
 	if s == "0" {
 		return "false"
@@ -350,6 +518,8 @@ func TestSetOutput(t *testing.T) {
 func TestHelp(t *testing.T) {
 	var helpCalled = false
 	fs := OneCmd("help test", ContinueOnError)
+	var out bytes.Buffer
+	fs.SetOutput(&out)
 	var flag bool
 	fs.BoolVar(&flag, "flag", false, "regular flag")
 	// Regular flag invocation should work
@@ -361,12 +531,15 @@ func TestHelp(t *testing.T) {
 		t.Error("flag was not set by -flag")
 	}
 	// Help flag should work as expected.
-	err = fs.Parse([]string{"-help"})
-	if err == nil {
-		t.Fatal("error expected")
-	}
-	if err.Error() != fmt.Errorf("flag provided but not defined: -help").Error() {
-		t.Fatal("expected ErrHelp; got ", err)
+	for _, arg := range []string{"-help", "--help", "-h"} {
+		out.Reset()
+		err = fs.Parse([]string{arg})
+		if err != ErrHelp {
+			t.Fatal("expected ErrHelp; got ", err)
+		}
+		if !strings.Contains(out.String(), "--flag") {
+			t.Fatalf("expected usage to be printed for %v; got %q", arg, out.String())
+		}
 	}
 	// If we define a help flag, that should override.
 	var help bool
@@ -381,6 +554,197 @@ func TestHelp(t *testing.T) {
 	}
 }
 
+func TestHelpSubCmd(t *testing.T) {
+	git := OneCmd("git", ContinueOnError)
+	var out bytes.Buffer
+	git.SetOutput(&out)
+	var commitErr error
+	git.SubCmd("commit", "commits the changes", func(commitCmd Cmd, args []string) {
+		commitCmd.String("branch", "", "branch name to work")
+		commitErr = commitCmd.Parse(args)
+	})
+	err := git.Parse([]string{"help"})
+	if err != ErrHelp {
+		t.Fatal("expected ErrHelp; got ", err)
+	}
+	if !strings.Contains(out.String(), "commits the changes") {
+		t.Fatalf("expected usage of git to be printed; got %q", out.String())
+	}
+	out.Reset()
+	err = git.Parse([]string{"help", "commit"})
+	if err != ErrHelp {
+		t.Fatal("expected ErrHelp; got ", err)
+	}
+	if commitErr != ErrHelp {
+		t.Fatal("expected ErrHelp from commit; got ", commitErr)
+	}
+	if !strings.Contains(out.String(), "git commit [<flags>]") || !strings.Contains(out.String(), "--branch") {
+		t.Fatalf("expected usage of commit to be printed; got %q", out.String())
+	}
+	git = OneCmd("git", ContinueOnError)
+	git.SetOutput(&out)
+	git.SubCmd("commit", "commits the changes", func(commitCmd Cmd, args []string) {
+		commitCmd.Parse(args)
+	})
+	err = git.Parse([]string{"commit", "--help"})
+	if err != ErrHelp {
+		t.Fatal("expected ErrHelp for commit --help; got ", err)
+	}
+	err = git.Parse([]string{"help", "push"})
+	if err == nil {
+		t.Fatal("expected error for help of an unknown sub command")
+	}
+}
+
+func TestHelpSubCmd_Path(t *testing.T) {
+	git := OneCmd("git", ContinueOnError)
+	var out bytes.Buffer
+	git.SetOutput(&out)
+	ran := false
+	var commitErr error
+	git.SubCmd("commit", "commits the changes", func(commitCmd Cmd, args []string) {
+		commitErr = commitCmd.Parse(args)
+		if commitErr != nil {
+			return
+		}
+		ran = true
+	})
+	git.SubCmd("remote", "manages the remotes", func(remoteCmd Cmd, args []string) {
+		remoteCmd.SubCmd("add", "adds a remote", func(addCmd Cmd, args []string) {
+			addCmd.String("url", "", "url of the remote")
+			if addCmd.Parse(args) == nil {
+				ran = true
+			}
+		})
+		if remoteCmd.Parse(args) == nil {
+			ran = true
+		}
+	})
+	err := git.Parse([]string{"help", "commit", "file.txt"})
+	if err != nil || commitErr == nil || commitErr == ErrHelp || ran {
+		t.Errorf("expected an error for the extra word without running commit but got %v (ran %v)", commitErr, ran)
+	}
+	out.Reset()
+	err = git.Parse([]string{"help", "remote", "add"})
+	if err != ErrHelp || ran {
+		t.Errorf("expected ErrHelp without running add but got %v (ran %v)", err, ran)
+	}
+	if !strings.Contains(out.String(), "git remote add [<flags>]") || !strings.Contains(out.String(), "--url") {
+		t.Errorf("expected the usage of add but got %q", out.String())
+	}
+}
+
+func TestParentFlagsBeforeSubCmd(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "custom.yaml")
+	writeCfg(t, path, "serve:\n  port: 9000\n")
+	tool := OneCmd("tool", ContinueOnError)
+	tool.SetCfgFile("tool")
+	mode := tool.String("mode", "", "a value which is also the name of a sub command")
+	var port int
+	var ranServe, ranRun bool
+	tool.SubCmd("serve", "", func(cmd Cmd, args []string) {
+		ranServe = true
+		cmd.IntVar(&port, "port", 80, "", cmd.Cfg("serve.port"))
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	tool.SubCmd("run", "", func(cmd Cmd, args []string) {
+		ranRun = true
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := tool.Parse([]string{"--config", path, "--mode", "serve", "run"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ranRun || ranServe || *mode != "serve" {
+		t.Errorf("expected run to run with mode serve but ran run %v, serve %v with mode %q", ranRun, ranServe, *mode)
+	}
+	err = tool.Parse([]string{"--config", path, "serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ranServe || port != 9000 {
+		t.Errorf("expected serve to run with port 9000 from the config of its parent but got %v", port)
+	}
+	err = tool.Parse([]string{"--mode", "serve", "deploy"})
+	if err == nil {
+		t.Error("expected an error for an unknown sub command after the flags of the parent")
+	}
+}
+
+func TestParentLoadsBeforeSubCmd(t *testing.T) {
+	dir := t.TempDir()
+	envPath := filepath.Join(dir, ".env")
+	cfgPath := filepath.Join(dir, "tool.yaml")
+	writeCfg(t, envPath, "TEST_PARENT_TOKEN=secret\n")
+	writeCfg(t, cfgPath, "serve:\n  port: 9000\n")
+	tool := OneCmd("tool", ContinueOnError)
+	tool.SetEnvOverlay(true)
+	envFile := tool.String("env-file", "", "", tool.Persistent())
+	cfgFile := tool.String("cfg", "", "", tool.Persistent())
+	tool.PersistentPreRun(func(cmd Cmd, args []string) error {
+		err := tool.LoadEnv(*envFile)
+		if err != nil {
+			return err
+		}
+		return tool.LoadCfg(*cfgFile)
+	})
+	var token string
+	var port int
+	serve := tool.NewSubCmd("serve", "", nil)
+	serve.StringVar(&token, "token", "", "", serve.Env("TEST_PARENT_TOKEN"), serve.Required())
+	serve.IntVar(&port, "port", 80, "", serve.Cfg("serve.port"))
+	var ranWith string
+	serve.Run(func(cmd Cmd, args []string) error {
+		ranWith = fmt.Sprint(token, " ", port)
+		return nil
+	})
+	err := tool.Parse([]string{"--env-file", envPath, "--cfg", cfgPath, "serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ranWith != "secret 9000" {
+		t.Errorf("expected serve to run with token secret and port 9000 loaded by the parent but got %q", ranWith)
+	}
+}
+
+func TestSubCmdDispatchInterspersed(t *testing.T) {
+	for _, tt := range []struct {
+		args  string
+		serve bool
+		files []string
+	}{
+		{"--verbose serve", true, nil},
+		{"pos serve --verbose", false, []string{"pos", "serve"}},
+		{"--verbose pos -- serve", false, []string{"pos", "serve"}},
+		{"--verbose -- serve", false, []string{"serve"}},
+	} {
+		tool := OneCmd("tool", ContinueOnError)
+		tool.SetInterspersed(true)
+		verbose := tool.Bool("verbose", false, "", tool.Persistent())
+		var files flagVar
+		tool.ArgVar(&files, "files", "", tool.VariadicArg(), tool.OptionalArg())
+		ranServe := false
+		tool.NewSubCmd("serve", "", func(ctx context.Context, cmd Cmd, args []string) error {
+			ranServe = true
+			return nil
+		})
+		err := tool.Parse(strings.Fields(tt.args))
+		if err != nil {
+			t.Fatalf("unexpected error for %q : %v", tt.args, err)
+		}
+		if ranServe != tt.serve || !*verbose || !reflect.DeepEqual([]string(files), tt.files) {
+			t.Errorf("%q: expected serve to run %v with files %v but got %v with %v (verbose %v)", tt.args, tt.serve, tt.files, ranServe, files, *verbose)
+		}
+	}
+}
+
 const defaultOutput = `"  -A\tfor bootstrapping, allow 'any' type\thas no default value\n  -Alongflagname\ndisable bounds checking\thas no default value\n  -C\ta boolean defaulting to true\tdefaults to [true]\n  -D path\nset relative path for local imports\thas no default value\n  -E string\nissue 23543\tdefaults to [0]\n  -F number\na non-zero number\tdefaults to [2.7]\n  -G float\na float that defaults to zero\thas no default value\n  -M string\na multiline\n    \thelp\n    \tstring\thas no default value\n  -N int\na non-zero int\tdefaults to [27]\n  -O\ta flag\n    \tmultiline help string\tdefaults to [true]\n  -Z int\nan int that defaults to zero\thas no default value\n  -maxT timeout\nset timeout for dial\thas no default value\n"`
 
 func mustPanic(t *testing.T, testName string, expected string, f func()) {
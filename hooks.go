@@ -0,0 +1,122 @@
+package flag
+
+// Hook runs around the handler of a command, cmd is the command being run and args are its positional arguments.
+// a Hook returning an error stops the hooks and the handler which didn't run yet,
+// the post run hooks still run for the pre run ones which did, to clean up after them.
+type Hook func(cmd Cmd, args []string) error
+
+// hooks of a command, see PreRun, Run, PostRun, PersistentPreRun and PersistentPostRun
+type hooks struct {
+	persistentPreRun  []Hook
+	preRun            []Hook
+	run               Hook
+	postRun           []Hook
+	persistentPostRun []Hook
+	ran               []*Command // commands whose persistent pre run hooks ran, from the root, the post run ones didn't yet
+	preRan            bool       // the pre run hooks of the command ran, its post run ones didn't yet
+}
+
+// PreRun registers a hook run by Parse after the flags and positional arguments of the command are parsed,
+// only when the command itself runs (not one of its sub commands).
+func (f *Command) PreRun(hook Hook) {
+	f.hooks.preRun = append(f.hooks.preRun, hook)
+}
+
+// Run sets the hook run by Parse after the pre run hooks, it replaces any earlier Run.
+// the code after Parse in the handler of a command is the same as its Run.
+func (f *Command) Run(hook Hook) {
+	f.hooks.run = hook
+}
+
+// PostRun registers a hook run after Run, or after the handler of the command returns if it has no Run.
+// a command without a handler (OneCmd) runs it at the end of Parse.
+func (f *Command) PostRun(hook Hook) {
+	f.hooks.postRun = append(f.hooks.postRun, hook)
+}
+
+// PersistentPreRun registers a hook run before the PreRun of the command and of all of its sub commands,
+// the persistent pre run hooks run from the root to the command being run, once all of its flags are parsed.
+// the config or env files loaded by them (like the ones named by a flag of the root) set the flags
+// before the required flags are checked and before the handler of the command being run continues.
+func (f *Command) PersistentPreRun(hook Hook) {
+	f.hooks.persistentPreRun = append(f.hooks.persistentPreRun, hook)
+}
+
+// PersistentPostRun registers a hook run after the PostRun of the command and of all of its sub commands,
+// the persistent post run hooks run from the command being run to the root, the reverse of the pre run ones.
+func (f *Command) PersistentPostRun(hook Hook) {
+	f.hooks.persistentPostRun = append(f.hooks.persistentPostRun, hook)
+}
+
+// runPersistentPreHooks runs the persistent pre run hooks from the root to f, which is the command being run.
+// if one fails, the persistent post run hooks matching the ones which ran are run right away.
+func (f *Command) runPersistentPreHooks() error {
+	var chain []*Command
+	for c := f; c != nil; c = c.parentCmd {
+		chain = append([]*Command{c}, chain...)
+	}
+	f.hooks.ran, f.hooks.preRan = nil, false
+	for _, c := range chain {
+		err := f.callHooks(c.hooks.persistentPreRun)
+		if err != nil {
+			f.runPostHooks()
+			return err
+		}
+		f.hooks.ran = append(f.hooks.ran, c)
+	}
+	return nil
+}
+
+// runHooks runs the pre run hooks and Run of f, after runPersistentPreHooks,
+// then the post run hooks if f has a Run or no handler to run them when it returns.
+// if a pre run hook fails, the post run hooks matching the pre run ones which ran are run right away.
+func (f *Command) runHooks() error {
+	err := f.callHooks(f.hooks.preRun)
+	if err != nil {
+		f.runPostHooks()
+		return err
+	}
+	f.hooks.preRan = true
+	if f.hooks.run != nil {
+		err = f.hooks.run(f, f.args)
+	}
+	if f.hooks.run != nil || !f.handled {
+		// the post run hooks run even if Run failed, to clean up after the pre run ones
+		postErr := f.runPostHooks()
+		if err == nil {
+			err = postErr
+		}
+	}
+	return err
+}
+
+// runPostHooks runs the post run hooks of f if its pre run hooks ran,
+// and the persistent post run hooks of the commands whose persistent pre run hooks ran, in reverse
+func (f *Command) runPostHooks() error {
+	ran, preRan := f.hooks.ran, f.hooks.preRan
+	f.hooks.ran, f.hooks.preRan = nil, false
+	if preRan {
+		err := f.callHooks(f.hooks.postRun)
+		if err != nil {
+			return err
+		}
+	}
+	for i := len(ran) - 1; i >= 0; i-- {
+		err := f.callHooks(ran[i].hooks.persistentPostRun)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// callHooks calls the hooks in order with f and its positional arguments, stopping at the first error
+func (f *Command) callHooks(hooks []Hook) error {
+	for _, hook := range hooks {
+		err := hook(f, f.args)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package flag_test
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestHooks(t *testing.T) {
+	var calls []string
+	hook := func(name string) Hook {
+		return func(cmd Cmd, args []string) error {
+			calls = append(calls, name)
+			return nil
+		}
+	}
+	app := OneCmd("app", ContinueOnError)
+	app.PersistentPreRun(hook("app persistent pre"))
+	app.PersistentPostRun(hook("app persistent post"))
+	app.PreRun(hook("app pre"))
+	app.SubCmd("db", "", func(db Cmd, args []string) {
+		db.PersistentPreRun(hook("db persistent pre"))
+		db.PersistentPostRun(hook("db persistent post"))
+		db.SubCmd("migrate", "", func(migrate Cmd, args []string) {
+			migrate.PreRun(hook("migrate pre"))
+			migrate.PostRun(hook("migrate post"))
+			err := migrate.Parse(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			calls = append(calls, "migrate handler")
+		})
+		err := db.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := app.Parse([]string{"db", "migrate"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"app persistent pre", "db persistent pre", "migrate pre",
+		"migrate handler",
+		"migrate post", "db persistent post", "app persistent post",
+	}
+	if !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected the hooks in order\n%v\nbut got\n%v", want, calls)
+	}
+}
+
+func TestHooks_Run(t *testing.T) {
+	var calls []string
+	app := OneCmd("app", ContinueOnError)
+	app.PreRun(func(cmd Cmd, args []string) error {
+		calls = append(calls, "pre")
+		return nil
+	})
+	app.Run(func(cmd Cmd, args []string) error {
+		calls = append(calls, "run "+args[0])
+		return nil
+	})
+	app.PostRun(func(cmd Cmd, args []string) error {
+		calls = append(calls, "post")
+		return nil
+	})
+	err := app.Parse([]string{"x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"pre", "run x", "post"}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("expected %v but got %v", want, calls)
+	}
+}
+
+func TestHooks_Error(t *testing.T) {
+	failed := errors.New("no database")
+	ran := false
+	app := OneCmd("app", ContinueOnError)
+	app.PersistentPreRun(func(cmd Cmd, args []string) error {
+		return failed
+	})
+	app.Run(func(cmd Cmd, args []string) error {
+		ran = true
+		return nil
+	})
+	err := app.Parse(nil)
+	if err != failed {
+		t.Errorf("expected the error of the hook but got %v", err)
+	}
+	if ran {
+		t.Error("expected Run not to run after a failing hook")
+	}
+}
+
+func TestHooks_ErrorRunsPostHooks(t *testing.T) {
+	failed := errors.New("no migrations")
+	var calls []string
+	hook := func(name string, err error) Hook {
+		return func(cmd Cmd, args []string) error {
+			calls = append(calls, name)
+			return err
+		}
+	}
+	for _, tt := range []struct {
+		failing string
+		want    []string
+	}{
+		{"migrate pre", []string{"app persistent pre", "db persistent pre", "migrate pre", "db persistent post", "app persistent post"}},
+		{"db persistent pre", []string{"app persistent pre", "db persistent pre", "app persistent post"}},
+	} {
+		calls = nil
+		errOf := func(name string) error {
+			if name == tt.failing {
+				return failed
+			}
+			return nil
+		}
+		app := OneCmd("app", ContinueOnError)
+		app.PersistentPreRun(hook("app persistent pre", nil))
+		app.PersistentPostRun(hook("app persistent post", nil))
+		db := app.NewSubCmd("db", "", nil)
+		db.PersistentPreRun(hook("db persistent pre", errOf("db persistent pre")))
+		db.PersistentPostRun(hook("db persistent post", nil))
+		migrate := db.NewSubCmd("migrate", "", func(ctx context.Context, cmd Cmd, args []string) error {
+			calls = append(calls, "migrate run")
+			return nil
+		})
+		migrate.PreRun(hook("migrate pre", errOf("migrate pre")))
+		migrate.PostRun(hook("migrate post", nil))
+		err := app.Parse([]string{"db", "migrate"})
+		if !errors.Is(err, failed) {
+			t.Errorf("%v: expected the error of the hook but got %v", tt.failing, err)
+		}
+		if !reflect.DeepEqual(calls, tt.want) {
+			t.Errorf("%v failing: expected the hooks\n%v\nbut got\n%v", tt.failing, tt.want, calls)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package flag
+
+import (
+	"sort"
+)
+
+// Persistent makes the flag you are defining accepted by this command and by all of its sub commands,
+// before the sub command (git --verbose commit) and after it (git commit --verbose).
+// the sub commands list it under "Global Flags" in their usage.
+func (fs *Command) Persistent() *flagFeature {
+	return &flagFeature{
+		index: 9,
+		add: func(fs *Command, f *Flag) {
+			f.global = true
+		},
+	}
+}
+
+// isPersistent reports whether flag, defined on f, or the flag it is an alias for is persistent
+func (f *Command) isPersistent(flag *Flag) bool {
+	if flag.aliasFor != "" {
+		flag = f.formal[flag.aliasFor]
+	}
+	return flag != nil && flag.global
+}
+
+// lookupFlag returns the flag named name defined on f or the persistent one of the nearest parent,
+// along with the command which defined it, nil if there is none
+func (f *Command) lookupFlag(name string) (*Flag, *Command) {
+	if flag := f.formal[name]; flag != nil {
+		return flag, f
+	}
+	for p := f.parentCmd; p != nil; p = p.parentCmd {
+		if flag := p.formal[name]; flag != nil && p.isPersistent(flag) {
+			return flag, p
+		}
+	}
+	return nil, nil
+}
+
+// inheritedFlags returns the persistent flags of the parents of f which f accepts, sorted by name
+func (f *Command) inheritedFlags() []*Flag {
+	seen := make(map[string]bool)
+	for name := range f.formal {
+		seen[name] = true
+	}
+	var flags []*Flag
+	for p := f.parentCmd; p != nil; p = p.parentCmd {
+		for _, flag := range sortFlags(p.formal) {
+			if !seen[flag.Name] && p.isPersistent(flag) {
+				seen[flag.Name] = true
+				flags = append(flags, flag)
+			}
+		}
+	}
+	sort.Slice(flags, func(i, j int) bool {
+		return flags[i].Name < flags[j].Name
+	})
+	return flags
+}
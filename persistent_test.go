@@ -0,0 +1,101 @@
+package flag_test
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestPersistent(t *testing.T) {
+	for _, args := range [][]string{
+		{"--verbose", "serve", "--port", "90"},
+		{"serve", "--verbose", "--port", "90"},
+		{"serve", "--port=90", "--verbose"},
+	} {
+		fs := OneCmd("app", ContinueOnError)
+		verbose := fs.Bool("verbose", false, "", fs.Persistent())
+		var port int
+		var verboseInSub bool
+		var usage string
+		fs.SubCmd("serve", "", func(cmd Cmd, args []string) {
+			cmd.IntVar(&port, "port", 80, "")
+			usage, _ = cmd.GetDefaultUsage()
+			err := cmd.Parse(args)
+			if err != nil {
+				t.Fatal(err)
+			}
+			verboseInSub = *verbose
+		})
+		err := fs.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !verboseInSub || port != 90 {
+			t.Errorf("expected verbose and port 90 for %v but got %v and %v", args, verboseInSub, port)
+		}
+		if !strings.Contains(usage, "Global Flags:") || !strings.Contains(usage, "--verbose") {
+			t.Errorf("expected verbose under the global flags of serve but got\n%v", usage)
+		}
+	}
+}
+
+func TestPersistent_NotPersistent(t *testing.T) {
+	fs := OneCmd("app", ContinueOnError)
+	fs.Bool("local", false, "")
+	fs.SubCmd("serve", "", func(cmd Cmd, args []string) {
+		err := cmd.Parse(args)
+		if err == nil {
+			t.Error("expected an error for a flag of the parent which is not persistent")
+		}
+	})
+	err := fs.Parse([]string{"serve", "--local"})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestPersistent_Env(t *testing.T) {
+	t.Setenv("APP_TOKEN", "secret")
+	fs := OneCmd("app", ContinueOnError)
+	token := fs.String("token", "", "", fs.Persistent(), fs.Env("APP_TOKEN"))
+	var got string
+	fs.SubCmd("serve", "", func(cmd Cmd, args []string) {
+		got = *token
+		err := cmd.Parse(args)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	err := fs.Parse([]string{"serve"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "secret" {
+		t.Errorf("expected the persistent flag resolved from the env before the sub command but got %q", got)
+	}
+}
+
+func TestPersistent_Required(t *testing.T) {
+	for args, wantErr := range map[string]bool{
+		"serve":                 true,
+		"--token x serve":       false,
+		"serve --token x":       false,
+		"serve --port 90":       true,
+		"--token x serve -p 90": false,
+	} {
+		fs := OneCmd("app", ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.String("token", "", "", fs.Persistent(), fs.Required())
+		serve := fs.NewSubCmd("serve", "", nil)
+		serve.Int("port", 80, "", serve.Alias("p"))
+		err := fs.Parse(strings.Fields(args))
+		if wantErr && (err == nil || !strings.Contains(err.Error(), "--token")) {
+			t.Errorf("expected the missing --token to be reported for %q but got %v", args, err)
+		}
+		if !wantErr && err != nil {
+			t.Errorf("unexpected error for %q : %v", args, err)
+		}
+	}
+}
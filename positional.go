@@ -0,0 +1,145 @@
+package flag
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Positional represents a declared positional argument of a command.
+type Positional struct {
+	Name  string // name as it appears in the usage, like <name>
+	Usage string // help message
+	Value Value  // value as set
+
+	optional bool
+	variadic bool
+}
+
+// Optional reports whether the argument can be omitted.
+func (p *Positional) Optional() bool { return p.optional }
+
+// Variadic reports whether the argument takes all the remaining arguments.
+func (p *Positional) Variadic() bool { return p.variadic }
+
+// usage returns the argument as shown in the usage line, <name>, [<name>], <name>... or [<name>...]
+func (p *Positional) usage() string {
+	u := "<" + p.Name + ">"
+	if p.variadic {
+		u += "..."
+	}
+	if p.optional {
+		u = "[" + u + "]"
+	}
+	return u
+}
+
+type argFeature struct {
+	add func(arg *Positional)
+}
+
+// OptionalArg marks the positional argument you are defining as optional,
+// only the trailing arguments can be optional.
+func (f *Command) OptionalArg() *argFeature {
+	return &argFeature{
+		add: func(arg *Positional) {
+			arg.optional = true
+		},
+	}
+}
+
+// VariadicArg makes the positional argument you are defining take all the remaining arguments,
+// Set of its Value is called once for each of them. it has to be the last argument,
+// it needs at least one argument unless it is also optional.
+func (f *Command) VariadicArg() *argFeature {
+	return &argFeature{
+		add: func(arg *Positional) {
+			arg.variadic = true
+		},
+	}
+}
+
+// ArgVar declares a positional argument with the specified name and usage string, in the order of the calls.
+// The type and value of the argument are represented by the first argument, of type Value, like Var.
+// Parse validates the count of the arguments and sets them, the usage shows them in the usage line.
+func (f *Command) ArgVar(value Value, name string, usage string, features ...*argFeature) {
+	arg := &Positional{Name: name, Usage: usage, Value: value}
+	for _, feature := range features {
+		feature.add(arg)
+	}
+	for _, p := range f.positionals {
+		if p.Name == arg.Name {
+			panic(fmt.Sprintf("argument redefined: %v", name))
+		}
+		if p.variadic {
+			panic(fmt.Sprintf("argument %v cannot be defined after the variadic argument %v", name, p.Name))
+		}
+		if p.optional && !arg.optional {
+			panic(fmt.Sprintf("required argument %v cannot be defined after the optional argument %v", name, p.Name))
+		}
+	}
+	f.positionals = append(f.positionals, arg)
+}
+
+// StringArg declares a string positional argument with specified name and usage string.
+// The return value is the address of a string variable that stores the value of the argument.
+func (f *Command) StringArg(name string, usage string, features ...*argFeature) *string {
+	p := new(string)
+	f.ArgVar(newStringValue("", p), name, usage, features...)
+	return p
+}
+
+// IntArg declares an int positional argument with specified name and usage string.
+// The return value is the address of an int variable that stores the value of the argument.
+func (f *Command) IntArg(name string, usage string, features ...*argFeature) *int {
+	p := new(int)
+	f.ArgVar(newIntValue(0, p), name, usage, features...)
+	return p
+}
+
+// Positionals returns the declared positional arguments in order.
+func (f *Command) Positionals() []*Positional {
+	return f.positionals
+}
+
+// parsePositionals validates the count of the arguments left after the flags and sets the declared positional arguments
+func (f *Command) parsePositionals() error {
+	if len(f.positionals) == 0 {
+		return nil
+	}
+	args := f.args
+	var missing []string
+	for _, p := range f.positionals {
+		if len(args) == 0 {
+			if !p.optional {
+				missing = append(missing, p.usage())
+			}
+			continue
+		}
+		n := 1
+		if p.variadic {
+			n = len(args)
+		}
+		for _, arg := range args[:n] {
+			if err := p.Value.Set(arg); err != nil {
+				return fmt.Errorf("invalid value %q for argument <%s>: %v", arg, p.Name, err)
+			}
+		}
+		args = args[n:]
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing argument/s %v", strings.Join(missing, " "))
+	}
+	if len(args) > 0 {
+		return fmt.Errorf("too many arguments %q, expected %v", args, strings.TrimSpace(f.argsUsage()))
+	}
+	return nil
+}
+
+// argsUsage returns the declared positional arguments as shown in the usage line
+func (f *Command) argsUsage() string {
+	u := ""
+	for _, p := range f.positionals {
+		u += " " + p.usage()
+	}
+	return u
+}
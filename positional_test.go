@@ -0,0 +1,82 @@
+package flag_test
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestPositionals(t *testing.T) {
+	var addArgs struct {
+		name, url string
+	}
+	var addErr error
+	var addUsage string
+	git := OneCmd("git", ContinueOnError)
+	git.SubCmd("remote", "", func(remoteCmd Cmd, args []string) {
+		remoteCmd.SubCmd("add", "adds a remote", func(addCmd Cmd, args []string) {
+			addCmd.Bool("fetch", false, "")
+			name := addCmd.StringArg("name", "name of the remote")
+			url := addCmd.StringArg("url", "url of the remote")
+			addErr = addCmd.Parse(args)
+			addArgs.name, addArgs.url = *name, *url
+			addUsage, _ = addCmd.GetDefaultUsage()
+		})
+		remoteCmd.Parse(args)
+	})
+
+	err := git.Parse([]string{"remote", "add", "origin", "https://example.com/repo.git"})
+	if err != nil || addErr != nil {
+		t.Fatal(err, addErr)
+	}
+	if addArgs.name != "origin" || addArgs.url != "https://example.com/repo.git" {
+		t.Errorf("unexpected arguments %+v", addArgs)
+	}
+	if !strings.Contains(addUsage, "git remote add [<flags>] <name> <url>") || !strings.Contains(addUsage, "<url> string  url of the remote") {
+		t.Errorf("expected the arguments in the usage:\n%v", addUsage)
+	}
+	git.Parse([]string{"remote", "add", "origin"})
+	if addErr == nil || addErr.Error() != "missing argument/s <url>" {
+		t.Errorf("expected missing argument error but got %v", addErr)
+	}
+	git.Parse([]string{"remote", "add", "origin", "url", "extra"})
+	if addErr == nil || addErr.Error() != `too many arguments ["extra"], expected <name> <url>` {
+		t.Errorf("expected too many arguments error but got %v", addErr)
+	}
+}
+
+func TestPositionalsOptionalAndVariadic(t *testing.T) {
+	cmd := OneCmd("cp", ContinueOnError)
+	count := cmd.IntArg("count", "")
+	var files flagVar
+	cmd.ArgVar(&files, "files", "", cmd.VariadicArg(), cmd.OptionalArg())
+
+	err := cmd.Parse([]string{"2", "a", "b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *count != 2 || !reflect.DeepEqual([]string(files), []string{"a", "b"}) {
+		t.Errorf("unexpected arguments count=%v files=%v", *count, files)
+	}
+	err = cmd.Parse([]string{"3"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = cmd.Parse([]string{"two"})
+	if err == nil {
+		t.Error("expected error for an invalid int argument")
+	}
+	err = cmd.Parse(nil)
+	if err == nil || err.Error() != "missing argument/s <count>" {
+		t.Errorf("expected missing argument error but got %v", err)
+	}
+	usage, _ := cmd.GetDefaultUsage()
+	if !strings.Contains(usage, "cp <count> [<files>...]") {
+		t.Errorf("expected the arguments in the usage line:\n%v", usage)
+	}
+	mustPanic(t, "argument after variadic", "argument extra cannot be defined after the variadic argument files", func() {
+		cmd.StringArg("extra", "")
+	})
+}
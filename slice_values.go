@@ -0,0 +1,311 @@
+package flag
+
+import (
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// SliceValue is implemented by the slice flag values, Set of a slice value splits the comma separated
+// values and the first Set replaces the default value while the later ones append.
+// A cfg value which is a list (YAML/JSON/TOML array) is set to a SliceValue using Replace.
+type SliceValue interface {
+	// Append parses val and adds it to the slice
+	Append(val string) error
+	// Replace parses vals and replaces the slice with them
+	Replace(vals []string) error
+	// GetSlice returns the slice as strings
+	GetSlice() []string
+}
+
+// MapValue is implemented by the map flag values, Set of a map value splits the comma separated
+// key=value pairs and the first Set replaces the default value while the later ones add to it.
+// A cfg value which is a table (YAML/JSON/TOML object) is set to a MapValue using ReplaceMap.
+type MapValue interface {
+	// ReplaceMap parses the values of m and replaces the map with them
+	ReplaceMap(m map[string]string) error
+	// GetMap returns the map with the values as strings
+	GetMap() map[string]string
+}
+
+// readCSV splits a comma separated value, quotes can be used for values containing a comma
+func readCSV(val string) ([]string, error) {
+	if val == "" {
+		return []string{}, nil
+	}
+	r := csv.NewReader(strings.NewReader(val))
+	r.TrimLeadingSpace = true
+	return r.Read()
+}
+
+func parseString(s string) (string, error) { return s, nil }
+
+func formatString(s string) string { return s }
+
+func parseInt(s string) (int, error) {
+	v, err := strconv.ParseInt(s, 0, strconv.IntSize)
+	if err != nil {
+		err = numError(err)
+	}
+	return int(v), err
+}
+
+func parseFloat64(s string) (float64, error) {
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		err = numError(err)
+	}
+	return v, err
+}
+
+func formatFloat64(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) }
+
+func parseDuration(s string) (time.Duration, error) {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		err = errParse
+	}
+	return v, err
+}
+
+func formatDuration(d time.Duration) string { return d.String() }
+
+// -- slice Value
+type sliceValue[T any] struct {
+	value   *[]T
+	changed bool
+	parse   func(string) (T, error)
+	format  func(T) string
+}
+
+func newSliceValue[T any](val []T, p *[]T, parse func(string) (T, error), format func(T) string) *sliceValue[T] {
+	*p = val
+	return &sliceValue[T]{value: p, parse: parse, format: format}
+}
+
+func (s *sliceValue[T]) Set(val string) error {
+	vals, err := readCSV(val)
+	if err != nil {
+		return errParse
+	}
+	if !s.changed {
+		return s.Replace(vals)
+	}
+	for _, v := range vals {
+		if err := s.Append(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *sliceValue[T]) Append(val string) error {
+	v, err := s.parse(val)
+	if err != nil {
+		return err
+	}
+	*s.value = append(*s.value, v)
+	return nil
+}
+
+func (s *sliceValue[T]) Replace(vals []string) error {
+	out := make([]T, 0, len(vals))
+	for _, val := range vals {
+		v, err := s.parse(val)
+		if err != nil {
+			return err
+		}
+		out = append(out, v)
+	}
+	*s.value = out
+	s.changed = true
+	return nil
+}
+
+func (s *sliceValue[T]) GetSlice() []string {
+	if s.value == nil {
+		return nil
+	}
+	strs := make([]string, 0, len(*s.value))
+	for _, v := range *s.value {
+		strs = append(strs, s.format(v))
+	}
+	return strs
+}
+
+func (s *sliceValue[T]) Get() any { return *s.value }
+
+func (s *sliceValue[T]) String() string { return "[" + strings.Join(s.GetSlice(), ",") + "]" }
+
+// -- map Value
+type mapValue[T any] struct {
+	value   *map[string]T
+	changed bool
+	parse   func(string) (T, error)
+	format  func(T) string
+}
+
+func newMapValue[T any](val map[string]T, p *map[string]T, parse func(string) (T, error), format func(T) string) *mapValue[T] {
+	*p = val
+	return &mapValue[T]{value: p, parse: parse, format: format}
+}
+
+func (m *mapValue[T]) Set(val string) error {
+	pairs, err := readCSV(val)
+	if err != nil {
+		return errParse
+	}
+	out := make(map[string]T, len(pairs))
+	if m.changed {
+		out = *m.value
+	}
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return fmt.Errorf("%q must be formatted as key=value", pair)
+		}
+		parsed, err := m.parse(v)
+		if err != nil {
+			return err
+		}
+		out[k] = parsed
+	}
+	*m.value = out
+	m.changed = true
+	return nil
+}
+
+func (m *mapValue[T]) ReplaceMap(vals map[string]string) error {
+	out := make(map[string]T, len(vals))
+	for k, v := range vals {
+		parsed, err := m.parse(v)
+		if err != nil {
+			return err
+		}
+		out[k] = parsed
+	}
+	*m.value = out
+	m.changed = true
+	return nil
+}
+
+func (m *mapValue[T]) GetMap() map[string]string {
+	if m.value == nil {
+		return nil
+	}
+	strs := make(map[string]string, len(*m.value))
+	for k, v := range *m.value {
+		strs[k] = m.format(v)
+	}
+	return strs
+}
+
+func (m *mapValue[T]) Get() any { return *m.value }
+
+func (m *mapValue[T]) String() string {
+	pairs := make([]string, 0)
+	for k, v := range m.GetMap() {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return "[" + strings.Join(pairs, ",") + "]"
+}
+
+// StringSliceVar defines a []string flag with specified name, default value, and usage string.
+// The argument p points to a []string variable in which to store the value of the flag.
+// The flag can be repeated and accepts comma separated values.
+func (f *Command) StringSliceVar(p *[]string, name string, value []string, usage string, features ...*flagFeature) {
+	f.Var(newSliceValue(value, p, parseString, formatString), name, usage, features...)
+}
+
+// StringSlice defines a []string flag with specified name, default value, and usage string.
+// The return value is the address of a []string variable that stores the value of the flag.
+// The flag can be repeated and accepts comma separated values.
+func (f *Command) StringSlice(name string, value []string, usage string, features ...*flagFeature) *[]string {
+	p := new([]string)
+	f.StringSliceVar(p, name, value, usage, features...)
+	return p
+}
+
+// IntSliceVar defines a []int flag with specified name, default value, and usage string.
+// The argument p points to a []int variable in which to store the value of the flag.
+// The flag can be repeated and accepts comma separated values.
+func (f *Command) IntSliceVar(p *[]int, name string, value []int, usage string, features ...*flagFeature) {
+	f.Var(newSliceValue(value, p, parseInt, strconv.Itoa), name, usage, features...)
+}
+
+// IntSlice defines a []int flag with specified name, default value, and usage string.
+// The return value is the address of a []int variable that stores the value of the flag.
+// The flag can be repeated and accepts comma separated values.
+func (f *Command) IntSlice(name string, value []int, usage string, features ...*flagFeature) *[]int {
+	p := new([]int)
+	f.IntSliceVar(p, name, value, usage, features...)
+	return p
+}
+
+// Float64SliceVar defines a []float64 flag with specified name, default value, and usage string.
+// The argument p points to a []float64 variable in which to store the value of the flag.
+// The flag can be repeated and accepts comma separated values.
+func (f *Command) Float64SliceVar(p *[]float64, name string, value []float64, usage string, features ...*flagFeature) {
+	f.Var(newSliceValue(value, p, parseFloat64, formatFloat64), name, usage, features...)
+}
+
+// Float64Slice defines a []float64 flag with specified name, default value, and usage string.
+// The return value is the address of a []float64 variable that stores the value of the flag.
+// The flag can be repeated and accepts comma separated values.
+func (f *Command) Float64Slice(name string, value []float64, usage string, features ...*flagFeature) *[]float64 {
+	p := new([]float64)
+	f.Float64SliceVar(p, name, value, usage, features...)
+	return p
+}
+
+// DurationSliceVar defines a []time.Duration flag with specified name, default value, and usage string.
+// The argument p points to a []time.Duration variable in which to store the value of the flag.
+// The flag can be repeated and accepts comma separated values acceptable to time.ParseDuration.
+func (f *Command) DurationSliceVar(p *[]time.Duration, name string, value []time.Duration, usage string, features ...*flagFeature) {
+	f.Var(newSliceValue(value, p, parseDuration, formatDuration), name, usage, features...)
+}
+
+// DurationSlice defines a []time.Duration flag with specified name, default value, and usage string.
+// The return value is the address of a []time.Duration variable that stores the value of the flag.
+// The flag can be repeated and accepts comma separated values acceptable to time.ParseDuration.
+func (f *Command) DurationSlice(name string, value []time.Duration, usage string, features ...*flagFeature) *[]time.Duration {
+	p := new([]time.Duration)
+	f.DurationSliceVar(p, name, value, usage, features...)
+	return p
+}
+
+// StringToStringVar defines a map[string]string flag with specified name, default value, and usage string.
+// The argument p points to a map[string]string variable in which to store the value of the flag.
+// The flag can be repeated and accepts comma separated key=value pairs.
+func (f *Command) StringToStringVar(p *map[string]string, name string, value map[string]string, usage string, features ...*flagFeature) {
+	f.Var(newMapValue(value, p, parseString, formatString), name, usage, features...)
+}
+
+// StringToString defines a map[string]string flag with specified name, default value, and usage string.
+// The return value is the address of a map[string]string variable that stores the value of the flag.
+// The flag can be repeated and accepts comma separated key=value pairs.
+func (f *Command) StringToString(name string, value map[string]string, usage string, features ...*flagFeature) *map[string]string {
+	p := new(map[string]string)
+	f.StringToStringVar(p, name, value, usage, features...)
+	return p
+}
+
+// StringToIntVar defines a map[string]int flag with specified name, default value, and usage string.
+// The argument p points to a map[string]int variable in which to store the value of the flag.
+// The flag can be repeated and accepts comma separated key=value pairs.
+func (f *Command) StringToIntVar(p *map[string]int, name string, value map[string]int, usage string, features ...*flagFeature) {
+	f.Var(newMapValue(value, p, parseInt, strconv.Itoa), name, usage, features...)
+}
+
+// StringToInt defines a map[string]int flag with specified name, default value, and usage string.
+// The return value is the address of a map[string]int variable that stores the value of the flag.
+// The flag can be repeated and accepts comma separated key=value pairs.
+func (f *Command) StringToInt(name string, value map[string]int, usage string, features ...*flagFeature) *map[string]int {
+	p := new(map[string]int)
+	f.StringToIntVar(p, name, value, usage, features...)
+	return p
+}
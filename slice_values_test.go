@@ -0,0 +1,102 @@
+package flag_test
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestSliceFlags(t *testing.T) {
+	fs := OneCmd("test", ContinueOnError)
+	tags := fs.StringSlice("tag", []string{"default"}, "")
+	ports := fs.IntSlice("port", nil, "")
+	ratios := fs.Float64Slice("ratio", nil, "")
+	timeouts := fs.DurationSlice("timeout", nil, "")
+	labels := fs.StringToString("label", map[string]string{"default": "yes"}, "")
+	limits := fs.StringToInt("limit", nil, "")
+
+	err := fs.Parse([]string{
+		"--tag", "a,b", "--tag", `"c,d"`,
+		"--port", "80", "--port", "443,8080",
+		"--ratio", "0.5,1",
+		"--timeout", "1s,2m",
+		"--label", "env=prod,team=core", "--label", "tier=1",
+		"--limit", "cpu=2,mem=512",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*tags, []string{"a", "b", "c,d"}) {
+		t.Errorf("unexpected tags %q", *tags)
+	}
+	if !reflect.DeepEqual(*ports, []int{80, 443, 8080}) {
+		t.Errorf("unexpected ports %v", *ports)
+	}
+	if !reflect.DeepEqual(*ratios, []float64{0.5, 1}) {
+		t.Errorf("unexpected ratios %v", *ratios)
+	}
+	if !reflect.DeepEqual(*timeouts, []time.Duration{time.Second, 2 * time.Minute}) {
+		t.Errorf("unexpected timeouts %v", *timeouts)
+	}
+	if !reflect.DeepEqual(*labels, map[string]string{"env": "prod", "team": "core", "tier": "1"}) {
+		t.Errorf("unexpected labels %v", *labels)
+	}
+	if !reflect.DeepEqual(*limits, map[string]int{"cpu": 2, "mem": 512}) {
+		t.Errorf("unexpected limits %v", *limits)
+	}
+	if s := fs.(*Command).Lookup("label").Value.String(); s != "[env=prod,team=core,tier=1]" {
+		t.Errorf("unexpected label string %v", s)
+	}
+	err = fs.Parse([]string{"--port", "eighty"})
+	if err == nil {
+		t.Error("expected error for an invalid int in a slice")
+	}
+	err = fs.Parse([]string{"--label", "novalue"})
+	if err == nil {
+		t.Error("expected error for a pair without =")
+	}
+}
+
+func TestSliceFlagsFromEnvAndCfg(t *testing.T) {
+	t.Setenv("TEST_SLICE_HOSTS", "a.com,b.com")
+	t.Setenv("TEST_MAP_LABELS", "env=dev")
+	configs := map[string]string{
+		"yaml": "server:\n  ports: [80, 443]\n  limits:\n    cpu: 2\n",
+		"json": `{"server": {"ports": [80, 443], "limits": {"cpu": 2}}}`,
+		"toml": "[server]\nports = [80, 443]\n[server.limits]\ncpu = 2\n",
+	}
+	for ext, content := range configs {
+		t.Run(ext, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cfg."+ext)
+			err := os.WriteFile(path, []byte(content), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			fs := OneCmd("test", ContinueOnError)
+			err = fs.LoadCfg(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			hosts := fs.StringSlice("host", nil, "", fs.Env("TEST_SLICE_HOSTS"))
+			labels := fs.StringToString("label", nil, "", fs.Env("TEST_MAP_LABELS"))
+			ports := fs.IntSlice("port", []int{1}, "", fs.Cfg("server.ports"))
+			limits := fs.StringToInt("limit", nil, "", fs.Cfg("server.limits"))
+			if !reflect.DeepEqual(*hosts, []string{"a.com", "b.com"}) {
+				t.Errorf("unexpected hosts %v", *hosts)
+			}
+			if !reflect.DeepEqual(*labels, map[string]string{"env": "dev"}) {
+				t.Errorf("unexpected labels %v", *labels)
+			}
+			if !reflect.DeepEqual(*ports, []int{80, 443}) {
+				t.Errorf("unexpected ports %v", *ports)
+			}
+			if !reflect.DeepEqual(*limits, map[string]int{"cpu": 2}) {
+				t.Errorf("unexpected limits %v", *limits)
+			}
+		})
+	}
+}
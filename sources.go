@@ -0,0 +1,364 @@
+package flag
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// SourceKind tells which kind of source the value of a flag came from.
+type SourceKind int
+
+// These constants are the kinds of sources a flag can get its value from.
+const (
+	SourceDefault SourceKind = iota // the default value of the flag
+	SourceEnv                       // an environment variable
+	SourceCfg                       // a key of the loaded config file
+	SourceArg                       // the command line arguments (or Command.Set)
+)
+
+func (k SourceKind) String() string {
+	switch k {
+	case SourceEnv:
+		return "env"
+	case SourceCfg:
+		return "cfg"
+	case SourceArg:
+		return "argument"
+	}
+	return "default"
+}
+
+// ValueSource tells where the value of a flag came from.
+type ValueSource struct {
+	Kind SourceKind
+	Key  string // name of the env, dot notation of the cfg or the flag as passed in the arguments, empty for the default
+	File string // path of the config file for SourceCfg
+}
+
+func (s ValueSource) String() string {
+	switch s.Kind {
+	case SourceDefault:
+		return "default"
+	case SourceCfg:
+		if s.File != "" {
+			return fmt.Sprintf("cfg %q in %v", s.Key, s.File)
+		}
+	}
+	return fmt.Sprintf("%v %q", s.Kind, s.Key)
+}
+
+// Source returns where the current value of the flag came from.
+func (f *Flag) Source() ValueSource {
+	if f.source == nil {
+		return ValueSource{}
+	}
+	return *f.source
+}
+
+func (f *Flag) setSource(source ValueSource) {
+	if f.source == nil {
+		f.source = &ValueSource{}
+	}
+	*f.source = source
+}
+
+// ValueSource returns where the value of the named flag, of the command or a persistent one of its parents, came from.
+func (f *Command) ValueSource(name string) (ValueSource, error) {
+	flag, _ := f.lookupFlag(name)
+	if flag == nil {
+		return ValueSource{}, fmt.Errorf("no such flag -%v", name)
+	}
+	return flag.Source(), nil
+}
+
+// PrintSourcesFlagName is the name of the flag defined by EnablePrintSources.
+const PrintSourcesFlagName = "print-config-sources"
+
+// GetValueSources returns a well formatted list of the flags (aliases excluded), their values and where the values came from,
+// for the command and its sub commands under the path of each command. print it to debug why a flag has the value it has.
+// the flags of a sub command added with SubCmd are listed once its handler ran, see NewSubCmd.
+func (f *Command) GetValueSources() string {
+	sources := ""
+	f.VisitCmds(func(cmd Cmd) {
+		c := cmd.(*Command)
+		if len(c.formal) == 0 {
+			return
+		}
+		sources += c.path() + ":\n"
+		for _, flag := range sortFlags(c.formal) {
+			if flag.aliasFor != "" {
+				continue
+			}
+			sources += fmt.Sprintf("  %v=%v  from %v\n", c.dashed(flag.Name), flag.Value.String(), flag.Source())
+		}
+	})
+	return sources
+}
+
+// EnablePrintSources defines the persistent flag --print-config-sources on the root command,
+// when it is passed Parse prints GetValueSources of the root to the output and returns ErrHelp like --help does.
+func (f *Command) EnablePrintSources() {
+	root := f.root()
+	root.BoolVar(&root.printSources, PrintSourcesFlagName, false, "prints the value of every flag and where it came from", root.Persistent(), root.NoAutoEnv(), root.NoAutoCfg())
+}
+
+// path returns the names of the commands from the root to f separated by spaces
+func (f *Command) path() string {
+	if f.parentCmd == nil {
+		return f.name
+	}
+	return f.parentCmd.path() + " " + f.name
+}
+
+// defaultPrecedence is the order of the sources tried by resolve unless SetPrecedence is called
+var defaultPrecedence = []SourceKind{SourceArg, SourceEnv, SourceCfg}
+
+// SetPrecedence sets the order in which the sources are tried for the value of a flag, it has to list
+// SourceArg, SourceEnv and SourceCfg exactly once, the default order is SourceArg, SourceEnv, SourceCfg.
+// sub commands use the precedence of their nearest parent which has set one.
+//
+// the value of a flag is resolved when it is defined, when a config is loaded with LoadCfg and
+// when Parse (or ParseWithoutArgs) is called, after reading the arguments. each time the first source in
+// the precedence which has a value for the flag sets it, so the result doesn't depend on the order the flags
+// are defined or LoadCfg is called. a flag is set by the arguments if it is passed in the arguments,
+// by an env if any of its envs (tried in the order they were bound) is not empty, and by a cfg if any of
+// its cfgs (tried in the order they were bound) is in the loaded config. when none of them has a value
+// the flag has its default value.
+func (f *Command) SetPrecedence(kinds ...SourceKind) {
+	seen := map[SourceKind]bool{}
+	for _, kind := range kinds {
+		if kind != SourceArg && kind != SourceEnv && kind != SourceCfg {
+			panic(fmt.Sprintf("precedence can only have SourceArg, SourceEnv and SourceCfg but got %v", kind))
+		}
+		if seen[kind] {
+			panic(fmt.Sprintf("%v is repeated in the precedence", kind))
+		}
+		seen[kind] = true
+	}
+	if len(seen) != len(defaultPrecedence) {
+		panic("precedence needs all of SourceArg, SourceEnv and SourceCfg")
+	}
+	f.precedence = kinds
+}
+
+// precedenceOrder returns the precedence set on f or its nearest parent
+func (f *Command) precedenceOrder() []SourceKind {
+	for c := f; c != nil; c = c.parentCmd {
+		if c.precedence != nil {
+			return c.precedence
+		}
+	}
+	return defaultPrecedence
+}
+
+// resolveAll resolves every flag of f
+func (f *Command) resolveAll() error {
+	for _, flag := range sortFlags(f.formal) {
+		err := f.resolve(flag)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resolve sets the value of the flag from the first source in the precedence which has a value for it,
+// see SetPrecedence. the arguments are already set by Parse, a flag whose env or cfg is gone gets back its default value.
+func (f *Command) resolve(flag *Flag) error {
+	if flag.aliasFor != "" {
+		// the flag it is an alias for is resolved
+		return nil
+	}
+	for _, kind := range f.precedenceOrder() {
+		switch kind {
+		case SourceArg:
+			if f.setByArg(flag) {
+				return nil
+			}
+		case SourceEnv:
+			if env, val, ok := f.lookupFlagEnv(flag); ok {
+				err := setSourceValue(flag, val)
+				if err != nil {
+					return fmt.Errorf("error while setting value from environment, flag name %v,env %v,value %v : %v", flag.Name, env, val, err)
+				}
+				flag.setSource(ValueSource{Kind: SourceEnv, Key: env})
+				return nil
+			}
+		case SourceCfg:
+			if notation, val, ok := f.lookupFlagCfg(flag); ok {
+				err := setSourceValue(flag, val)
+				if err != nil {
+					return fmt.Errorf("unable to set notation %v value %v to flag %v : %v", notation, val, flag.Name, err)
+				}
+				flag.setSource(ValueSource{Kind: SourceCfg, Key: notation, File: f.CfgOrigin(notation)})
+				return nil
+			}
+		}
+	}
+	if kind := flag.Source().Kind; kind == SourceEnv || kind == SourceCfg {
+		return flag.resetToDefault()
+	}
+	return nil
+}
+
+// setByArg reports whether the flag or any of its aliases was passed in the arguments
+func (f *Command) setByArg(flag *Flag) bool {
+	if f.actual[flag.Name] != nil {
+		return true
+	}
+	for alias := range flag.alias {
+		if f.actual[alias] != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// lookupFlagEnv returns the first env of the flag which is not empty
+func (f *Command) lookupFlagEnv(flag *Flag) (env string, val string, ok bool) {
+	for _, env := range flag.envList {
+		val, _ := f.LookupEnv(env)
+		if val != "" {
+			return env, val, true
+		}
+	}
+	return "", "", false
+}
+
+// lookupFlagCfg returns the first cfg of the flag which is in the loaded config
+func (f *Command) lookupFlagCfg(flag *Flag) (notation string, val interface{}, ok bool) {
+	if f.cfg == nil {
+		return "", nil, false
+	}
+	for _, notation := range flag.cfgList {
+		val, err := getRawValueByDotNotation(*f.cfg, notation)
+		if err == nil && val != nil && val != "" {
+			return notation, val, true
+		}
+	}
+	return "", nil, false
+}
+
+// setSourceValue sets the value of an env or a cfg to the flag, replacing the value of a SliceValue or a MapValue
+// instead of appending to it, lists of the config are set to a SliceValue and tables to a MapValue as they are
+func setSourceValue(to *Flag, val interface{}) error {
+	switch val := val.(type) {
+	case string:
+		switch v := to.Value.(type) {
+		case SliceValue:
+			vals, err := readCSV(val)
+			if err != nil {
+				return errParse
+			}
+			return v.Replace(vals)
+		case MapValue:
+			pairs, err := readCSV(val)
+			if err != nil {
+				return errParse
+			}
+			vals := make(map[string]string, len(pairs))
+			for _, pair := range pairs {
+				k, v, ok := strings.Cut(pair, "=")
+				if !ok {
+					return fmt.Errorf("%q must be formatted as key=value", pair)
+				}
+				vals[k] = v
+			}
+			return v.ReplaceMap(vals)
+		}
+		return to.Set(val)
+	case []interface{}:
+		if sv, ok := to.Value.(SliceValue); ok {
+			vals := make([]string, 0, len(val))
+			for _, v := range val {
+				s, err := jsonnify(v)
+				if err != nil {
+					return err
+				}
+				vals = append(vals, s)
+			}
+			return sv.Replace(vals)
+		}
+	case map[string]interface{}:
+		if mv, ok := to.Value.(MapValue); ok {
+			vals := make(map[string]string, len(val))
+			for k, v := range val {
+				s, err := jsonnify(v)
+				if err != nil {
+					return err
+				}
+				vals[k] = s
+			}
+			return mv.ReplaceMap(vals)
+		}
+	}
+	s, err := jsonnify(val)
+	if err != nil {
+		return err
+	}
+	return to.Set(s)
+}
+
+// setFromArg sets a value passed in the arguments, the value of a SliceValue or a MapValue set by another source
+// is replaced instead of appended to
+func (f *Flag) setFromArg(value string) error {
+	if f.Source().Kind != SourceArg {
+		switch v := f.Value.(type) {
+		case SliceValue:
+			if err := v.Replace(nil); err != nil {
+				return err
+			}
+		case MapValue:
+			if err := v.ReplaceMap(nil); err != nil {
+				return err
+			}
+		}
+	}
+	return f.Set(value)
+}
+
+// defaultSetter returns a func setting value back to the value it has now, the default value of its flag,
+// nil if the flag is set back with Set(DefValue). slices and maps are copied, so are the built in scalar values,
+// a Value defined by the user may point to more than it holds, it is set from DefValue.
+func defaultSetter(value Value) func() error {
+	switch v := value.(type) {
+	case SliceValue:
+		def := v.GetSlice()
+		return func() error { return v.Replace(def) }
+	case MapValue:
+		def := v.GetMap()
+		return func() error { return v.ReplaceMap(def) }
+	case *boolValue, *intValue, *int64Value, *uintValue, *uint64Value, *stringValue, *float64Value, *durationValue:
+		return pointeeSetter(reflect.ValueOf(v))
+	case funcValue:
+		// there is no value to set back, only a func to call
+		return func() error { return nil }
+	}
+	return nil
+}
+
+// pointeeSetter returns a func setting the value p points to back to the value it has now
+func pointeeSetter(p reflect.Value) func() error {
+	def := reflect.New(p.Elem().Type()).Elem()
+	def.Set(p.Elem())
+	return func() error {
+		p.Elem().Set(def)
+		return nil
+	}
+}
+
+// resetToDefault sets the default value back to the flag
+func (f *Flag) resetToDefault() error {
+	var err error
+	if f.reset != nil {
+		err = f.reset()
+	} else {
+		err = f.Value.Set(f.DefValue)
+	}
+	if err != nil {
+		return fmt.Errorf("unable to reset flag %v to its default value %v : %v", f.Name, f.DefValue, err)
+	}
+	f.setSource(ValueSource{})
+	return nil
+}
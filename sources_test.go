@@ -0,0 +1,206 @@
+package flag_test
+
+import (
+	"context"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	. "github.com/ondbyte/turbo_flag"
+)
+
+func TestValueSource(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	err := os.WriteFile(path, []byte("server:\n  port: 9000\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_SOURCE_HOST", "example.com")
+	fs := OneCmd("test", ContinueOnError)
+	err = fs.LoadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs.Int("port", 8080, "", fs.Cfg("server.port"))
+	fs.String("host", "localhost", "", fs.Env("TEST_SOURCE_HOST"))
+	fs.String("name", "turbo", "", fs.Alias("n"))
+	fs.Bool("verbose", false, "")
+
+	err = fs.Parse([]string{"-n", "flag"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := map[string]ValueSource{
+		"port":    {Kind: SourceCfg, Key: "server.port", File: path},
+		"host":    {Kind: SourceEnv, Key: "TEST_SOURCE_HOST"},
+		"name":    {Kind: SourceArg, Key: "-n"},
+		"n":       {Kind: SourceArg, Key: "-n"},
+		"verbose": {Kind: SourceDefault},
+	}
+	for name, want := range tests {
+		got, err := fs.ValueSource(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("expected source of %v to be %v but got %v", name, want, got)
+		}
+	}
+	if _, err := fs.ValueSource("nope"); err == nil {
+		t.Error("expected error for an undefined flag")
+	}
+	sources := fs.GetValueSources()
+	for _, line := range []string{
+		`--port=9000  from cfg "server.port" in ` + path,
+		`--host=example.com  from env "TEST_SOURCE_HOST"`,
+		`--name=flag  from argument "-n"`,
+		`--verbose=false  from default`,
+	} {
+		if !strings.Contains(sources, line) {
+			t.Errorf("expected sources to contain %q:\n%v", line, sources)
+		}
+	}
+	if strings.Contains(sources, "--n=") {
+		t.Errorf("aliases should not be listed:\n%v", sources)
+	}
+}
+
+func TestValueSource_Persistent(t *testing.T) {
+	fs := OneCmd("app", ContinueOnError)
+	fs.Bool("verbose", false, "", fs.Persistent())
+	serve := fs.NewSubCmd("serve", "", nil)
+	err := fs.Parse([]string{"serve", "--verbose"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := serve.ValueSource("verbose")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (ValueSource{Kind: SourceArg, Key: "--verbose"}); got != want {
+		t.Errorf("expected the source of the persistent flag to be %v but got %v", want, got)
+	}
+}
+
+func TestPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cfg.yaml")
+	err := os.WriteFile(path, []byte("port: 9000\nhost: cfg.com\ntags: [cfg]\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("TEST_PRECEDENCE_PORT", "7000")
+	t.Setenv("TEST_PRECEDENCE_TAGS", "env1,env2")
+
+	fs := OneCmd("test", ContinueOnError)
+	port := fs.Int("port", 8080, "", fs.Env("TEST_PRECEDENCE_PORT"), fs.Cfg("port"))
+	host := fs.String("host", "localhost", "", fs.Env("TEST_PRECEDENCE_HOST"), fs.Cfg("host"))
+	tags := fs.StringSlice("tag", nil, "", fs.Env("TEST_PRECEDENCE_TAGS"), fs.Cfg("tags"))
+	// loading the config after defining the flags must not beat the env
+	err = fs.LoadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 7000 || *host != "cfg.com" {
+		t.Errorf("expected env > cfg > default but got port=%v host=%v", *port, *host)
+	}
+	err = fs.Parse([]string{"--port", "6000", "--tag", "arg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 6000 || !reflect.DeepEqual(*tags, []string{"arg"}) {
+		t.Errorf("expected args > env but got port=%v tags=%v", *port, *tags)
+	}
+	// loading the config again must not beat the args
+	err = fs.LoadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 6000 {
+		t.Errorf("expected args > cfg after LoadCfg but got port=%v", *port)
+	}
+
+	fs = OneCmd("test", ContinueOnError)
+	fs.SetPrecedence(SourceCfg, SourceEnv, SourceArg)
+	err = fs.LoadCfg(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	port = fs.Int("port", 8080, "", fs.Env("TEST_PRECEDENCE_PORT"), fs.Cfg("port"))
+	tags = fs.StringSlice("tag", nil, "", fs.Env("TEST_PRECEDENCE_TAGS"), fs.Cfg("missing"))
+	err = fs.Parse([]string{"--port", "6000", "--tag", "arg"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *port != 9000 || !reflect.DeepEqual(*tags, []string{"env1", "env2"}) {
+		t.Errorf("expected cfg > env > args but got port=%v tags=%v", *port, *tags)
+	}
+	if src, _ := fs.ValueSource("port"); src.Kind != SourceCfg {
+		t.Errorf("expected port to come from cfg but got %v", src)
+	}
+	mustPanic(t, "incomplete precedence", "precedence needs all of SourceArg, SourceEnv and SourceCfg", func() {
+		fs.SetPrecedence(SourceArg, SourceEnv)
+	})
+}
+
+func TestPrintSources(t *testing.T) {
+	t.Setenv("TEST_SOURCE_PORT", "9000")
+	var out strings.Builder
+	app := OneCmd("app", ContinueOnError)
+	app.SetOutput(&out)
+	app.EnablePrintSources()
+	app.String("token", "t", "")
+	serve := app.NewSubCmd("serve", "", func(ctx context.Context, cmd Cmd, args []string) error {
+		t.Error("serve should not run when printing the sources")
+		return nil
+	})
+	serve.Int("port", 80, "", serve.Env("TEST_SOURCE_PORT"))
+	err := app.Parse([]string{"serve", "--print-config-sources"})
+	if err != ErrHelp {
+		t.Fatalf("expected ErrHelp but got %v", err)
+	}
+	for _, line := range []string{"app:\n", "  --token=t  from default\n", "app serve:\n", `  --port=9000  from env "TEST_SOURCE_PORT"`} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("expected the sources of the tree to contain %q:\n%v", line, out.String())
+		}
+	}
+}
+
+func TestResetToDefault(t *testing.T) {
+	t.Setenv("TEST_RESET_TAGS", "x")
+	t.Setenv("TEST_RESET_LABELS", "k=v")
+	fs := OneCmd("test", ContinueOnError)
+	tags := fs.StringSlice("tags", []string{"a,b", "[c]"}, "", fs.Env("TEST_RESET_TAGS"))
+	labels := fs.StringToString("labels", map[string]string{"team": "a=b"}, "", fs.Env("TEST_RESET_LABELS"))
+	endpoint := &url.URL{Scheme: "https", Host: "default.example"}
+	fs.Var(&URLValue{endpoint}, "endpoint", "", fs.Cfg("endpoint"))
+	dir := t.TempDir()
+	writeCfg(t, filepath.Join(dir, "set.json"), `{"endpoint": "https://cfg.example"}`)
+	writeCfg(t, filepath.Join(dir, "unset.json"), `{}`)
+	err := fs.LoadCfg(filepath.Join(dir, "set.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fs.Parse(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*tags) != 1 || endpoint.Host != "cfg.example" {
+		t.Fatalf("expected the values from the envs and the cfg but got %v and %v", *tags, endpoint)
+	}
+	for _, env := range []string{"TEST_RESET_TAGS", "TEST_RESET_LABELS"} {
+		os.Unsetenv(env)
+	}
+	err = fs.LoadCfg(filepath.Join(dir, "unset.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(*tags, []string{"a,b", "[c]"}) || !reflect.DeepEqual(*labels, map[string]string{"team": "a=b"}) || endpoint.String() != "https://default.example" {
+		t.Errorf("expected the defaults back but got %q, %v and %v", *tags, *labels, endpoint)
+	}
+	if src, _ := fs.ValueSource("endpoint"); src.Kind != SourceDefault {
+		t.Errorf("expected endpoint to come from its default but got %v", src)
+	}
+}
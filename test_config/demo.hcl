@@ -0,0 +1,3 @@
+database {
+  password = "12345"
+}